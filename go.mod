@@ -2,7 +2,10 @@ module github.com/nick-we/go_ssa_no_nil_linter
 
 go 1.25.4
 
-require golang.org/x/tools v0.39.0
+require (
+	golang.org/x/tools v0.39.0
+	google.golang.org/protobuf v1.36.11
+)
 
 require (
 	golang.org/x/mod v0.30.0 // indirect
@@ -2,40 +2,129 @@ package analyzer
 
 import (
 	"golang.org/x/tools/go/analysis"
-	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
 )
 
 // NewAnalyzer constructs the top-level analysis.Analyzer used by the CLI.
 func NewAnalyzer() *analysis.Analyzer {
-	return &analysis.Analyzer{
+	return NewAnalyzerWithConfig(DefaultConfig())
+}
+
+// NewAnalyzerWithConfig constructs an analysis.Analyzer driven by cfg. The
+// configuration fields are also exposed as flags on the returned analyzer.
+func NewAnalyzerWithConfig(cfg *Config) *analysis.Analyzer {
+	a := &analysis.Analyzer{
 		Name: "grpcnil",
-		Doc:  "detect nil values in gRPC response messages",
-		Run:  run,
-		Requires: []*analysis.Analyzer{
-			buildssa.Analyzer,
+		Doc: `detect nil values in gRPC response messages
+
+Optional fields that consumer code dereferences without a nil check are
+reported as required too. Such requirements reach a handler only from
+its own package and the packages it imports: consumers the server does
+not import, such as a separate cmd/ client, are not seen.`,
+		Run: func(pass *analysis.Pass) (any, error) {
+			return run(pass, cfg)
 		},
+		FactTypes: []analysis.Fact{new(summaryFact), new(contractFact), new(requirementsFact)},
 	}
+	cfg.registerFlags(&a.Flags)
+	return a
 }
 
 // run is the entry point invoked by the analysis framework for each package.
-func run(pass *analysis.Pass) (any, error) {
-	// Obtain SSA built by the shared buildssa pass, which includes imports like context.
-	res, ok := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
-	if !ok || res == nil {
+func run(pass *analysis.Pass, cfg *Config) (any, error) {
+	// Dependencies outside the analyzed module are neither checked nor
+	// summarized; callers fall back to the bodiless default for them.
+	if !inAnalyzedModule(pass) {
 		return nil, nil
 	}
+	ssaPkg, srcFuncs := buildSSA(pass)
+
+	descriptors, err := cfg.descriptorIndex()
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize core analyzers.
 	protoAnalyzer := NewProtoFieldAnalyzer()
+	protoAnalyzer.UseDescriptors(descriptors)
 	nilAnalyzer := NewNilFlowAnalyzer()
+	if nilAnalyzer.BodilessDefault, err = cfg.bodilessStatus(); err != nil {
+		return nil, err
+	}
+	if nilAnalyzer.FieldLoadDefault, err = cfg.fieldLoadStatus(); err != nil {
+		return nil, err
+	}
+	for _, name := range cfg.noReturnNames() {
+		nilAnalyzer.NoReturn[name] = true
+	}
+	nilAnalyzer.Funcs = srcFuncs
+	if init := ssaPkg.Func("init"); init != nil {
+		nilAnalyzer.Funcs = append(append([]*ssa.Function(nil), srcFuncs...), init)
+	}
+	resolver, err := newDynamicCallResolver(ssaPkg.Prog, cfg.CallGraph, cfg.ExcludeImpls)
+	if err != nil {
+		return nil, err
+	}
+	if resolver != nil {
+		nilAnalyzer.DynamicCallees = resolver.Callees
+	}
+	nilAnalyzer.ImportSummary = summaryImporter(pass)
+	nilAnalyzer.ImportContract = contractImporter(pass)
+
+	contracts, problems := collectContracts(pass.Files, pass.TypesInfo)
+	reportDirectiveProblems(pass, problems)
+	nilAnalyzer.Contracts = contracts
+	exportContracts(pass, contracts)
+
+	// Fields that consumers in this package or the packages it imports
+	// dereference without a nil check become implicit requirements before
+	// any handler is analyzed.
+	exportRequirements(pass, mineImplicitRequirements(pass.Fset, srcFuncs, protoAnalyzer))
+	importRequirements(pass, protoAnalyzer)
+
+	exportSummaries(pass, nilAnalyzer, srcFuncs)
+
+	if cfg.DumpSchema != "" {
+		for _, named := range lookupSchemaType(protoAnalyzer, pass.Pkg, cfg.DumpSchema) {
+			tree := BuildSchemaTree(protoAnalyzer, named)
+			if err := cfg.writeSchemaOnce(named.Obj(), tree); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	verifyContracts(pass, nilAnalyzer, srcFuncs)
 
 	// Walk all source functions in this package and treat those that look like
 	// gRPC handlers as analysis roots.
-	for _, fn := range res.SrcFuncs {
+	for _, fn := range srcFuncs {
 		if h := DetectHandlerFromFunc(fn); h != nil {
-			analyzeHandler(pass, protoAnalyzer, nilAnalyzer, *h)
+			analyzeHandler(pass, protoAnalyzer, nilAnalyzer, *h, cfg.MaxNestedDepth)
+			reportPolicyMismatches(pass, protoAnalyzer, *h)
 		}
 	}
 
 	return nil, nil
 }
+
+// reportPolicyMismatches reports disagreements between struct tags and the
+// descriptor set that surfaced while analyzing h. Fields declared in the
+// current package are reported at their declaration, others at the handler.
+func reportPolicyMismatches(pass *analysis.Pass, protoAnalyzer *ProtoFieldAnalyzer, h HandlerInfo) {
+	for _, m := range protoAnalyzer.TakeMismatches() {
+		pos := h.Function.Pos()
+		if m.Field.Pkg() == pass.Pkg {
+			pos = m.Field.Pos()
+		}
+		pass.Reportf(
+			pos,
+			"field policy mismatch for %s.%s: struct tags classify it as %s, descriptor %s as %s",
+			m.Message.Obj().Name(),
+			m.FieldName,
+			m.HeuristicRisk,
+			m.ProtoFullName,
+			m.DescriptorRisk,
+		)
+	}
+}
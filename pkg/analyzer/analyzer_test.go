@@ -1,10 +1,20 @@
 package analyzer_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/nick-we/go_ssa_no_nil_linter/pkg/analyzer"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // TestDirectNilAssignment verifies that the analyzer flags a direct assignment
@@ -28,6 +38,16 @@ func TestSubNilAssignment(t *testing.T) {
 	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "subnil")
 }
 
+// TestMaxNestedDepth verifies that sub-messages below -max-nested-depth
+// are not validated, and that nested slice elements are reported by their
+// full path.
+func TestMaxNestedDepth(t *testing.T) {
+	testdata := analysistest.TestData()
+	cfg := analyzer.DefaultConfig()
+	cfg.MaxNestedDepth = 1
+	analysistest.Run(t, testdata, analyzer.NewAnalyzerWithConfig(cfg), "nesteddepth")
+}
+
 // TestComplexFlowAssignment verifies the analyzer behavior in more complex
 // control-flow scenarios (if/else, switch, and sub-function calls) where
 // the nilness of non-optional response fields depends on the path.
@@ -44,3 +64,331 @@ func TestDateNilAssignment(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "datenil")
 }
+
+// TestDescriptorSetPolicies verifies that field policies are taken from a
+// FileDescriptorSet when one is configured, and that disagreements with the
+// struct-tag heuristics are reported.
+func TestDescriptorSetPolicies(t *testing.T) {
+	message := func(name string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			TypeName: proto.String(".users.v1." + name),
+		}
+	}
+	profile := message("UserProfile")
+	profile.Name, profile.Number, profile.Proto3Optional = proto.String("profile"), proto.Int32(1), proto.Bool(true)
+	avatar := message("Image")
+	avatar.Name, avatar.Number = proto.String("avatar"), proto.Int32(2)
+	settings := message("Settings")
+	settings.Name, settings.Number = proto.String("settings"), proto.Int32(3)
+
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("users/v1/users.proto"),
+			Package: proto.String("users.v1"),
+			Syntax:  proto.String("proto3"),
+			Options: &descriptorpb.FileOptions{GoPackage: proto.String("descriptorset;usersv1")},
+			MessageType: []*descriptorpb.DescriptorProto{
+				{Name: proto.String("GetUserRequest")},
+				{Name: proto.String("GetUserResponse"), Field: []*descriptorpb.FieldDescriptorProto{profile, avatar, settings}},
+				{Name: proto.String("UserProfile")},
+				{Name: proto.String("Image")},
+				{Name: proto.String("Settings")},
+			},
+		}},
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "users.binpb")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := analyzer.NewAnalyzer()
+	if err := a.Flags.Set("descriptor-set", path); err != nil {
+		t.Fatal(err)
+	}
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, a, "descriptorset")
+}
+
+// TestClientUsageRequirements verifies that schema-optional fields which
+// consumer code dereferences without a dominating nil check are treated as
+// implicitly required, including by the packages importing that code, and
+// that diagnostics cite the consuming site.
+func TestClientUsageRequirements(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "clientnil")
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "clientx/pb", "clientx/client", "clientx/svc")
+}
+
+// TestDumpSchema verifies the -dump-schema mode in text and JSON form,
+// including termination on recursive message types.
+func TestDumpSchema(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	var text bytes.Buffer
+	cfg := analyzer.DefaultConfig()
+	cfg.DumpSchema = "Node"
+	cfg.Output = &text
+	analysistest.Run(t, testdata, analyzer.NewAnalyzerWithConfig(cfg), "schemadump")
+
+	for _, want := range []string{
+		"schemadump.Node\n",
+		`  Parent *schemadump.Node ` + "`" + `protobuf:"bytes,2,opt,name=parent,proto3"` + "`" + ` optional=false repeated=false map=false risk="message pointer" reason="sub-message pointer without oneof tag"`,
+		"    schemadump.Node (recursive, see above)\n",
+		`  Meta *schemadump.Meta`,
+		`risk="safe" reason="oneof sub-message; nil is allowed"`,
+		`  Attrs map[string]*schemadump.Meta`,
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text dump does not contain %q:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	cfg = analyzer.DefaultConfig()
+	cfg.DumpSchema = "schemadump.Node"
+	cfg.DumpFormat = "json"
+	cfg.Output = &out
+	analysistest.Run(t, testdata, analyzer.NewAnalyzerWithConfig(cfg), "schemadump")
+
+	var node analyzer.SchemaNode
+	if err := json.Unmarshal(out.Bytes(), &node); err != nil {
+		t.Fatalf("invalid JSON dump: %v\n%s", err, out.String())
+	}
+	if len(node.Fields) != 5 {
+		t.Fatalf("got %d fields, want 5", len(node.Fields))
+	}
+	children := node.Fields[2]
+	if children.Risk != "repeated message pointer" || !children.IsRepeated || children.Message == nil || !children.Message.Recursive {
+		t.Errorf("unexpected Children field: %+v", children)
+	}
+	if meta := node.Fields[3].Message; meta == nil || meta.Recursive || len(meta.Fields) != 1 {
+		t.Errorf("unexpected Meta sub-tree: %+v", meta)
+	}
+}
+
+// TestNestedSubMessages verifies that sub-messages placed into a response are
+// validated recursively, with full field paths and termination on recursive
+// message graphs.
+func TestNestedSubMessages(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "nestednil")
+}
+
+// summaryAnalyzer reports the NilFlowAnalyzer summary of every top-level
+// function returning a pointer, so that summaries can be asserted with want
+// comments.
+var summaryAnalyzer = &analysis.Analyzer{
+	Name:     "summaries",
+	Doc:      "report nil-flow function summaries",
+	Requires: []*analysis.Analyzer{buildssa.Analyzer},
+	Run: func(pass *analysis.Pass) (any, error) {
+		res := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+		nilAnalyzer := analyzer.NewNilFlowAnalyzer()
+		for _, fn := range res.SrcFuncs {
+			results := fn.Signature.Results()
+			if results.Len() == 0 || fn.Parent() != nil {
+				continue
+			}
+			if _, ok := results.At(0).Type().Underlying().(*types.Pointer); !ok {
+				continue
+			}
+			pass.Reportf(fn.Pos(), "summary %s: %s", fn.Name(), nilAnalyzer.Summary(fn))
+		}
+		return nil, nil
+	},
+}
+
+// TestParamSummaries verifies that function summaries are expressed in terms
+// of parameters and instantiated with the argument statuses at call sites.
+func TestParamSummaries(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, summaryAnalyzer, "paramsummary")
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "paramflow")
+}
+
+// TestRecursiveSummaries verifies that callee summaries are computed from
+// full value analysis and that recursion is resolved to a fixpoint.
+func TestRecursiveSummaries(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, summaryAnalyzer, "recursummary")
+}
+
+// TestBodilessCallees verifies that callees from other packages are
+// summarized from the facts of their package, and otherwise treated as not
+// analyzed, never silently non-nil.
+func TestBodilessCallees(t *testing.T) {
+	dir := filepath.Join(analysistest.TestData(), "modules", "bodiless")
+	analysistest.Run(t, dir, analyzer.NewAnalyzer(), "example.com/bodiless/svc")
+}
+
+// TestNilCheckRefinement verifies that stores dominated by a nil check of
+// the stored value are not flagged.
+func TestNilCheckRefinement(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "nilcheck")
+}
+
+// TestErrorCorrelatedResults verifies that a result which is nil only
+// alongside a non-nil error is accepted after the error is checked.
+func TestErrorCorrelatedResults(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, summaryAnalyzer, "errsummary")
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "errflow")
+}
+
+// TestFieldLoads verifies that fields loaded from local messages are traced
+// to the stores into them, and that other loads use -field-load-default.
+func TestFieldLoads(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "fieldflow")
+
+	cfg := analyzer.DefaultConfig()
+	cfg.FieldLoadDefault = "notnil"
+	analysistest.Run(t, testdata, analyzer.NewAnalyzerWithConfig(cfg), "fieldflownotnil")
+}
+
+// TestDynamicCalls verifies that interface method and function value calls
+// are resolved through the call graph, ignoring excluded mock packages.
+func TestDynamicCalls(t *testing.T) {
+	testdata := analysistest.TestData()
+	for _, algorithm := range []string{"cha", "vta"} {
+		cfg := analyzer.DefaultConfig()
+		cfg.CallGraph = algorithm
+		cfg.ExcludeImpls = "...mocks"
+		analysistest.Run(t, testdata, analyzer.NewAnalyzerWithConfig(cfg), "ifacecall")
+	}
+}
+
+// TestClosures verifies that closure bodies are summarized and that
+// variables captured and assigned by closures are tracked.
+func TestClosures(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "closureflow")
+}
+
+// TestTypeAssertions verifies the nilness of type assertion results,
+// including comma-ok assertions refined by their ok branch.
+func TestTypeAssertions(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "typeassert")
+}
+
+// TestSharedState verifies that package variables and service fields only
+// ever assigned non-nil values are proven non-nil at their loads.
+func TestSharedState(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "sharedstate")
+}
+
+// TestContainerLoads verifies the nilness of map lookups and slice element
+// loads, including comma-ok lookups refined by their ok branch.
+func TestContainerLoads(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "containerflow")
+}
+
+// TestModels verifies the built-in summaries of protobuf runtime and
+// well-known type constructors, which have no bodies in the analysis.
+func TestModels(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "wktmodels")
+}
+
+// TestContracts verifies that //grpcnil: directives are trusted at call
+// sites and loads, in their package and in importing ones, and that code
+// breaking them is reported.
+func TestContracts(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "contracts")
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "xcontracts/clib", "xcontracts/svc")
+}
+
+// TestSummaryFacts verifies that function summaries, including the
+// response fields a helper sets, are exported as facts and used by
+// importing packages.
+func TestSummaryFacts(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "factsum/helpers", "factsum/svc")
+}
+
+// TestStdlibImports verifies that a module importing the standard library
+// is analyzed in module mode without summarizing its dependencies.
+func TestStdlibImports(t *testing.T) {
+	dir := filepath.Join(analysistest.TestData(), "modules", "stdimports")
+	results := analysistest.Run(t, dir, analyzer.NewAnalyzer(), "example.com/stdimports/...")
+	for _, r := range results {
+		for _, f := range r.Action.AllObjectFacts() {
+			if path := f.Object.Pkg().Path(); !strings.Contains(strings.Split(path, "/")[0], ".") {
+				t.Errorf("fact exported for %s in standard library package %s: %s", f.Object.Name(), path, f.Fact)
+			}
+		}
+		for _, f := range r.Action.AllPackageFacts() {
+			if path := f.Package.Path(); !strings.Contains(strings.Split(path, "/")[0], ".") {
+				t.Errorf("fact exported for standard library package %s: %s", path, f.Fact)
+			}
+		}
+	}
+}
+
+// TestGetters verifies that generated getters are modeled as field loads
+// with a nil-safe receiver, precisely along chains of getters.
+func TestGetters(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "getterflow")
+}
+
+// TestFanOut verifies that values received from channels are traced to the
+// sends on them, and that goroutines and errgroup tasks filling in the
+// response are checked.
+func TestFanOut(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "fanout")
+}
+
+// TestLoopFixpoint verifies that loop-carried pointers are iterated to a
+// fixpoint from bottom instead of being treated as unknown.
+func TestLoopFixpoint(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "loopflow")
+}
+
+// TestNoReturn verifies that paths ending in calls that never return, built
+// in, inferred or configured with -no-return, are pruned.
+func TestNoReturn(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, summaryAnalyzer, "noreturnsum")
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "noreturn")
+
+	cfg := analyzer.DefaultConfig()
+	cfg.NoReturn = "(*zaplike.Logger).Fatal"
+	analysistest.Run(t, testdata, analyzer.NewAnalyzerWithConfig(cfg), "noreturncfg")
+}
+
+// TestDeferredFixups verifies that deferred closures patching named results
+// or response fields run before the value leaves the function.
+func TestDeferredFixups(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, summaryAnalyzer, "defersum")
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "deferfix")
+}
+
+// TestAliasedStores verifies that stores through local aliases of field
+// addresses and through pointer parameters of callees are attributed to the
+// response fields.
+func TestAliasedStores(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "aliasflow")
+}
+
+// TestResponseInstances verifies that only the response allocations
+// reaching a return are checked, each on its own.
+func TestResponseInstances(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, analyzer.NewAnalyzer(), "respinstance")
+}
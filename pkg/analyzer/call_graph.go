@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// dynamicCallResolver resolves the dynamic calls of a program through a call
+// graph, which is built on first use.
+type dynamicCallResolver struct {
+	prog      *ssa.Program
+	algorithm string
+	exclude   []*regexp.Regexp
+
+	once  sync.Once
+	sites map[ssa.CallInstruction][]*ssa.Function
+}
+
+// newDynamicCallResolver returns a resolver for prog using algorithm ("cha"
+// or "vta"), or nil for "none". Callees declared in packages matching one of
+// the comma-separated exclude patterns are ignored.
+func newDynamicCallResolver(prog *ssa.Program, algorithm, exclude string) (*dynamicCallResolver, error) {
+	switch algorithm {
+	case "none":
+		return nil, nil
+	case "cha", "vta":
+	default:
+		return nil, fmt.Errorf("invalid -call-graph %q: want cha, vta or none", algorithm)
+	}
+	r := &dynamicCallResolver{prog: prog, algorithm: algorithm}
+	for _, pattern := range strings.Split(exclude, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			r.exclude = append(r.exclude, packagePatternRegexp(pattern))
+		}
+	}
+	return r, nil
+}
+
+// Callees returns the possible callees of the dynamic call site call.
+func (r *dynamicCallResolver) Callees(call ssa.CallInstruction) []*ssa.Function {
+	r.once.Do(r.build)
+	return r.sites[call]
+}
+
+func (r *dynamicCallResolver) build() {
+	cg := cha.CallGraph(r.prog)
+	if r.algorithm == "vta" {
+		cg = vta.CallGraph(ssautil.AllFunctions(r.prog), cg)
+	}
+
+	r.sites = make(map[ssa.CallInstruction][]*ssa.Function)
+	callgraph.GraphVisitEdges(cg, func(e *callgraph.Edge) error {
+		if e.Site == nil || e.Site.Common().StaticCallee() != nil || r.excluded(e.Callee.Func) {
+			return nil
+		}
+		r.sites[e.Site] = append(r.sites[e.Site], e.Callee.Func)
+		return nil
+	})
+}
+
+// excluded reports whether fn is declared in an excluded package.
+func (r *dynamicCallResolver) excluded(fn *ssa.Function) bool {
+	pkg := fn.Pkg
+	if pkg == nil && fn.Origin() != nil {
+		pkg = fn.Origin().Pkg
+	}
+	if pkg == nil {
+		return false
+	}
+	for _, re := range r.exclude {
+		if re.MatchString(pkg.Pkg.Path()) {
+			return true
+		}
+	}
+	return false
+}
+
+// packagePatternRegexp compiles a package pattern as understood by the go
+// command, where "..." matches any string, e.g. "example.com/.../mocks".
+func packagePatternRegexp(pattern string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(pattern)
+	return regexp.MustCompile("^" + strings.ReplaceAll(quoted, `\.\.\.`, ".*") + "$")
+}
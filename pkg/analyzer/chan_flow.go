@@ -0,0 +1,233 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// chanSend is a value sent on a channel by a send statement or select.
+type chanSend struct {
+	val   ssa.Value
+	instr ssa.Instruction
+}
+
+// recvFact computes the fact of v if it is a value received from a channel:
+// a receive, the value of a comma-ok receive, or a value received by a
+// select. Receives are traced to the sends on the channel; a closed channel
+// also yields the zero value unless edges show the ok result to be true.
+func (a *NilFlowAnalyzer) recvFact(v ssa.Value, edges []edge) (nilFact, bool) {
+	ch, okVal, isRecv := receivedFrom(v)
+	if !isRecv {
+		return nilFact{}, false
+	}
+	f, closed := a.chanElemFact(ch, v.Parent())
+	if closed {
+		if okVal == nil || !holdsOn(okVal, edges) {
+			f = f.join(zeroFact(ch.Type().Underlying().(*types.Chan).Elem()))
+		}
+	}
+	return f, true
+}
+
+// receivedFrom returns the channel the value v is received from and the ok
+// result of the receive, if any.
+func receivedFrom(v ssa.Value) (ssa.Value, ssa.Value, bool) {
+	switch v := v.(type) {
+	case *ssa.UnOp:
+		if v.Op == token.ARROW && !v.CommaOk {
+			return v.X, nil, true
+		}
+	case *ssa.Extract:
+		switch tuple := v.Tuple.(type) {
+		case *ssa.UnOp:
+			if tuple.Op == token.ARROW && v.Index == 0 {
+				return tuple.X, tupleExtract(tuple, 1), true
+			}
+		case *ssa.Select:
+			// The values received by the receive states follow the index
+			// of the chosen state and its ok result.
+			k := v.Index - 2
+			for _, st := range tuple.States {
+				if st.Dir != types.RecvOnly {
+					continue
+				}
+				if k == 0 {
+					return st.Chan, tupleExtract(tuple, 1), true
+				}
+				k--
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// holdsOn reports whether edges show the boolean v to be true.
+func holdsOn(v ssa.Value, edges []edge) bool {
+	holds, ok := edgesHold(v, edges)
+	return ok && holds
+}
+
+// tupleExtract returns the extraction of result i of tuple, or nil.
+func tupleExtract(tuple ssa.Value, i int) ssa.Value {
+	for _, ref := range *tuple.Referrers() {
+		if ext, ok := ref.(*ssa.Extract); ok && ext.Index == i {
+			return ext
+		}
+	}
+	return nil
+}
+
+// chanElemFact joins the facts of the values sent on the channels ch may
+// be, relative to the parameters of fn, and reports whether one of them
+// may be closed. Only channels made locally and used solely by the
+// functions and closures that make them are traced; other channels yield
+// FieldLoadDefault.
+func (a *NilFlowAnalyzer) chanElemFact(ch ssa.Value, fn *ssa.Function) (nilFact, bool) {
+	origins, ok := chanOrigins(ch, make(map[ssa.Value]bool))
+	if !ok {
+		return nilFact{Status: a.FieldLoadDefault}, false
+	}
+	f := nilFact{Status: nilStatusBottom}
+	closed := false
+	for _, mc := range origins {
+		if a.tracing[mc] {
+			// A value received from the channel is sent back on it.
+			continue
+		}
+		sends, c, escaped := chanUses(mc)
+		if escaped {
+			return nilFact{Status: a.FieldLoadDefault}, false
+		}
+		closed = closed || c
+		a.tracing[mc] = true
+		for _, s := range sends {
+			f = f.join(a.liftedFactAt(s.val, s.instr, fn))
+		}
+		delete(a.tracing, mc)
+	}
+	return f, closed
+}
+
+// chanOrigins returns the channels made by MakeChan that v may be, following
+// Phis, local variables and parameters of closures.
+func chanOrigins(v ssa.Value, seen map[ssa.Value]bool) ([]*ssa.MakeChan, bool) {
+	if seen[v] {
+		return nil, true
+	}
+	seen[v] = true
+	var srcs []ssa.Value
+	switch v := v.(type) {
+	case *ssa.MakeChan:
+		return []*ssa.MakeChan{v}, true
+	case *ssa.Const:
+		// Receiving from a nil channel blocks forever.
+		return nil, true
+	case *ssa.ChangeType:
+		srcs = []ssa.Value{v.X}
+	case *ssa.Phi:
+		srcs = v.Edges
+	case *ssa.UnOp:
+		// A load of a variable, e.g. one captured by a goroutine.
+		vals, ok := variableStores(v.X)
+		if v.Op != token.MUL || !ok {
+			return nil, false
+		}
+		srcs = vals
+	case *ssa.Parameter:
+		fn := v.Parent()
+		sites, ok := closureCalls(fn)
+		if !ok || len(sites) == 0 {
+			return nil, false
+		}
+		i := paramIndexOf(fn, v)
+		for _, site := range sites {
+			srcs = append(srcs, site.Common().Args[i])
+		}
+	default:
+		return nil, false
+	}
+	var out []*ssa.MakeChan
+	for _, src := range srcs {
+		origins, ok := chanOrigins(src, seen)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, origins...)
+	}
+	return out, true
+}
+
+// chanUses returns the sends on the channel made by mc through any of its
+// aliases, and whether it is closed. The channel escapes if an alias is
+// used other than to send, receive, close or measure it, to store it into a
+// local variable, or to pass it to a closure of the function.
+func chanUses(mc *ssa.MakeChan) (sends []chanSend, closed, escaped bool) {
+	aliases := []ssa.Value{mc}
+	seen := map[ssa.Value]bool{mc: true}
+	alias := func(v ssa.Value) {
+		if !seen[v] {
+			seen[v] = true
+			aliases = append(aliases, v)
+		}
+	}
+	for i := 0; i < len(aliases); i++ {
+		ch := aliases[i]
+		for _, ref := range *ch.Referrers() {
+			switch ref := ref.(type) {
+			case *ssa.Send:
+				if ref.X == ch {
+					return nil, false, true
+				}
+				sends = append(sends, chanSend{ref.X, ref})
+			case *ssa.UnOp:
+				if ref.Op != token.ARROW {
+					return nil, false, true
+				}
+			case *ssa.Select:
+				for _, st := range ref.States {
+					if st.Send == ch {
+						return nil, false, true
+					}
+					if st.Chan == ch && st.Dir == types.SendOnly {
+						sends = append(sends, chanSend{st.Send, ref})
+					}
+				}
+			case *ssa.Store:
+				cell := variableCell(ref.Addr)
+				if ref.Val != ch || cell == nil || addrEscapes(cell) {
+					return nil, false, true
+				}
+				for _, load := range variableLoads(cell) {
+					alias(load)
+				}
+			case *ssa.Phi:
+				alias(ref)
+			case *ssa.ChangeType:
+				alias(ref)
+			case ssa.CallInstruction:
+				c := ref.Common()
+				switch {
+				case isBuiltin(*c, "close"):
+					closed = true
+				case isBuiltin(*c, "len"), isBuiltin(*c, "cap"):
+				default:
+					fn := closureCallee(c)
+					if fn == nil {
+						return nil, false, true
+					}
+					for j, arg := range c.Args {
+						if arg == ch {
+							alias(fn.Params[j])
+						}
+					}
+				}
+			case *ssa.DebugRef:
+			default:
+				return nil, false, true
+			}
+		}
+	}
+	return sends, closed, false
+}
@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// messageField identifies a field of a proto message type.
+type messageField struct {
+	msg   *types.Named
+	field string
+}
+
+// requiredField is an implicit requirement mined from consumer code: the
+// field named Field of the message identified by Message (see messageKey)
+// is dereferenced without a nil check at Site, e.g. "client.go:12".
+type requiredField struct {
+	Message string
+	Field   string
+	Site    string
+}
+
+// mineImplicitRequirements scans consumer code for sub-message fields that
+// are dereferenced without a dominating nil check or nil-safe getter, e.g.
+//
+//	resp.GetUser().Profile.Name
+//	resp.Profile.Avatar
+//
+// Each such field is marked as an implicit requirement on protoAnalyzer,
+// citing the first consuming site found, and returned for export to the
+// packages importing this one.
+func mineImplicitRequirements(fset *token.FileSet, fns []*ssa.Function, protoAnalyzer *ProtoFieldAnalyzer) []requiredField {
+	var mined []requiredField
+	seen := make(map[messageField]bool)
+	for _, fn := range fns {
+		checks := nilChecks(fn)
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				base, ok := dereferencedValue(instr)
+				if !ok {
+					continue
+				}
+				mf, ok := loadedMessageField(base)
+				if !ok || seen[mf] || checkedBefore(checks[mf], instr) {
+					continue
+				}
+				seen[mf] = true
+				pos := fset.Position(instr.Pos())
+				site := fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line)
+				protoAnalyzer.MarkImplicitRequirement(mf.msg, mf.field, site)
+				mined = append(mined, requiredField{Message: messageKey(mf.msg), Field: mf.field, Site: site})
+			}
+		}
+	}
+	return mined
+}
+
+// dereferencedValue returns the pointer that instr dereferences, if any.
+func dereferencedValue(instr ssa.Instruction) (ssa.Value, bool) {
+	switch in := instr.(type) {
+	case *ssa.FieldAddr:
+		return in.X, true
+	case *ssa.UnOp:
+		if in.Op == token.MUL {
+			return in.X, true
+		}
+	}
+	return nil, false
+}
+
+// loadedMessageField reports which sub-message field v was read from, either
+// by a direct load (*&x.F) or through a generated getter (x.GetF()).
+func loadedMessageField(v ssa.Value) (messageField, bool) {
+	switch val := v.(type) {
+	case *ssa.UnOp:
+		if val.Op != token.MUL {
+			break
+		}
+		fa, ok := val.X.(*ssa.FieldAddr)
+		if !ok {
+			break
+		}
+		msg := receiverNamedType(fa.X.Type())
+		if msg == nil || !implementsProtoMessage(msg) {
+			break
+		}
+		field := fieldVar(msg, fa.Field)
+		if field == nil || !isPointer(field.Type()) || !isProtoMessage(field.Type()) {
+			break
+		}
+		return messageField{msg: msg, field: field.Name()}, true
+	case *ssa.Call:
+		callee := val.Call.StaticCallee()
+		if callee == nil {
+			break
+		}
+		method, _ := callee.Object().(*types.Func)
+		msg, field := protoGetterField(method)
+		if field == nil || !isProtoMessage(field.Type()) {
+			break
+		}
+		return messageField{msg: msg, field: field.Name()}, true
+	}
+	return messageField{}, false
+}
+
+// nilChecks collects the comparisons against nil of the sub-message fields
+// that fn loads.
+func nilChecks(fn *ssa.Function) map[messageField][]ssa.Instruction {
+	checks := make(map[messageField][]ssa.Instruction)
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			bin, ok := instr.(*ssa.BinOp)
+			if !ok || (bin.Op != token.EQL && bin.Op != token.NEQ) {
+				continue
+			}
+			operand := bin.X
+			if isNilConst(operand) {
+				operand = bin.Y
+			} else if !isNilConst(bin.Y) {
+				continue
+			}
+			if mf, ok := loadedMessageField(operand); ok {
+				checks[mf] = append(checks[mf], bin)
+			}
+		}
+	}
+	return checks
+}
+
+// checkedBefore reports whether instr only runs after one of checks found
+// the field non-nil: its block is dominated by the edge the comparison
+// takes when the field is not nil, as in branchStatus.
+func checkedBefore(checks []ssa.Instruction, instr ssa.Instruction) bool {
+	for _, check := range checks {
+		pred := check.Block()
+		ifInstr := branchIf(pred)
+		if ifInstr == nil || ifInstr.Cond != check.(ssa.Value) {
+			continue
+		}
+		succ := pred.Succs[0]
+		if check.(*ssa.BinOp).Op == token.EQL {
+			succ = pred.Succs[1]
+		}
+		if len(succ.Preds) == 1 && succ.Dominates(instr.Block()) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNilConst(v ssa.Value) bool {
+	c, ok := v.(*ssa.Const)
+	return ok && c.IsNil()
+}
+
+// fieldVar returns the i-th field of the struct underlying named.
+func fieldVar(named *types.Named, i int) *types.Var {
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok || i < 0 || i >= st.NumFields() {
+		return nil
+	}
+	return st.Field(i)
+}
@@ -0,0 +1,249 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// taskStarters lists the methods that run their closure argument in a new
+// goroutine, keyed by types.Func.FullName.
+var taskStarters = map[string]bool{
+	"(*golang.org/x/sync/errgroup.Group).Go":    true,
+	"(*golang.org/x/sync/errgroup.Group).TryGo": true,
+}
+
+// launchedClosure returns the closure instr calls or starts: a direct call,
+// a go or defer statement, or a call of a task starter such as errgroup's
+// Group.Go with the closure as its argument. async reports whether the
+// closure may run after instr returns.
+func launchedClosure(instr ssa.Instruction) (mc *ssa.MakeClosure, async bool) {
+	var c *ssa.CallCommon
+	switch instr := instr.(type) {
+	case *ssa.Call:
+		c = &instr.Call
+	case *ssa.Go:
+		c, async = &instr.Call, true
+	case *ssa.Defer:
+		c, async = &instr.Call, true
+	default:
+		return nil, false
+	}
+	if mc, ok := c.Value.(*ssa.MakeClosure); ok {
+		for _, arg := range c.Args {
+			if arg == mc {
+				return nil, false
+			}
+		}
+		return mc, async
+	}
+	callee := c.StaticCallee()
+	if callee == nil || len(c.Args) != 2 {
+		return nil, false
+	}
+	method, _ := callee.Object().(*types.Func)
+	if method == nil || !taskStarters[method.FullName()] {
+		return nil, false
+	}
+	mc, _ = c.Args[1].(*ssa.MakeClosure)
+	return mc, true
+}
+
+// closureArgs returns the arguments the closure launched by instr runs
+// with, in the order of its parameters and free variables. Parameters are
+// nil when the closure is started by a task starter.
+func closureArgs(instr ssa.Instruction) []ssa.Value {
+	mc, _ := launchedClosure(instr)
+	c := instr.(ssa.CallInstruction).Common()
+	if c.Value == mc {
+		return commonArgs(c)
+	}
+	fn := mc.Fn.(*ssa.Function)
+	return append(make([]ssa.Value, len(fn.Params)), mc.Bindings...)
+}
+
+// variableCell returns the local variable whose cell addr is, following the
+// free variables through which closures capture it, or nil.
+func variableCell(addr ssa.Value) *ssa.Alloc {
+	for {
+		switch v := addr.(type) {
+		case *ssa.Alloc:
+			return v
+		case *ssa.FreeVar:
+			addr = freeVarBinding(v)
+		default:
+			return nil
+		}
+	}
+}
+
+// cellAliases returns the cell and the free variables of the closures
+// capturing it, transitively.
+func cellAliases(cell *ssa.Alloc) []ssa.Value {
+	aliases := []ssa.Value{cell}
+	for i := 0; i < len(aliases); i++ {
+		for _, ref := range *aliases[i].Referrers() {
+			if mc, ok := ref.(*ssa.MakeClosure); ok {
+				fn := mc.Fn.(*ssa.Function)
+				for j, b := range mc.Bindings {
+					if b == aliases[i] {
+						aliases = append(aliases, fn.FreeVars[j])
+					}
+				}
+			}
+		}
+	}
+	return aliases
+}
+
+// variableStores returns the values stored into the non-escaping local
+// variable with cell addr, by its function or the closures capturing it.
+func variableStores(addr ssa.Value) ([]ssa.Value, bool) {
+	cell := variableCell(addr)
+	if cell == nil || addrEscapes(cell) {
+		return nil, false
+	}
+	var vals []ssa.Value
+	for _, alias := range cellAliases(cell) {
+		for _, ref := range *alias.Referrers() {
+			if store, ok := ref.(*ssa.Store); ok && store.Addr == alias {
+				vals = append(vals, store.Val)
+			}
+		}
+	}
+	return vals, true
+}
+
+// variableLoads returns the loads of the local variable with cell addr, by
+// its function or the closures capturing it.
+func variableLoads(addr ssa.Value) []ssa.Value {
+	cell := variableCell(addr)
+	if cell == nil {
+		return nil
+	}
+	var loads []ssa.Value
+	for _, alias := range cellAliases(cell) {
+		for _, ref := range *alias.Referrers() {
+			if load, ok := ref.(*ssa.UnOp); ok && load.Op == token.MUL {
+				loads = append(loads, load)
+			}
+		}
+	}
+	return loads
+}
+
+// freeVarBinding returns the value bound to the free variable fv where its
+// closure is created, or nil if the closure is created more than once.
+func freeVarBinding(fv *ssa.FreeVar) ssa.Value {
+	fn := fv.Parent()
+	mc := closureSite(fn)
+	if mc == nil {
+		return nil
+	}
+	for i, v := range fn.FreeVars {
+		if v == fv {
+			return mc.Bindings[i]
+		}
+	}
+	return nil
+}
+
+// closureCallee returns the anonymous function called by c, or nil if c
+// calls anything else.
+func closureCallee(c *ssa.CallCommon) *ssa.Function {
+	switch v := c.Value.(type) {
+	case *ssa.Function:
+		if v.Parent() != nil {
+			return v
+		}
+	case *ssa.MakeClosure:
+		return v.Fn.(*ssa.Function)
+	}
+	return nil
+}
+
+// closureCalls returns the call, go and defer instructions invoking the
+// anonymous function fn, and false if fn is used in any other way, e.g.
+// handed to another function.
+func closureCalls(fn *ssa.Function) ([]ssa.CallInstruction, bool) {
+	if fn.Parent() == nil {
+		return nil, false
+	}
+	var v ssa.Value = fn
+	if len(fn.FreeVars) > 0 {
+		mc := closureSite(fn)
+		if mc == nil {
+			return nil, false
+		}
+		v = mc
+	}
+	var calls []ssa.CallInstruction
+	for _, ref := range *v.Referrers() {
+		switch ref := ref.(type) {
+		case ssa.CallInstruction:
+			c := ref.Common()
+			if c.Value != v {
+				return nil, false
+			}
+			for _, arg := range c.Args {
+				if arg == v {
+					return nil, false
+				}
+			}
+			calls = append(calls, ref)
+		case *ssa.DebugRef:
+		default:
+			return nil, false
+		}
+	}
+	return calls, true
+}
+
+// liftedFactAt returns the fact of v at instr relative to the parameters of
+// fn, when instr belongs to fn or to a closure nested in it: the free
+// variables and parameters of each enclosing closure are replaced by the
+// values it is created and called with. Facts of unrelated functions are
+// resolved.
+func (a *NilFlowAnalyzer) liftedFactAt(v ssa.Value, instr ssa.Instruction, fn *ssa.Function) nilFact {
+	return a.liftTo(a.factAt(v, instr), instr.Parent(), fn)
+}
+
+// liftTo lifts the fact f, relative to the parameters of from, out of the
+// closures enclosing from up to fn.
+func (a *NilFlowAnalyzer) liftTo(f nilFact, from, fn *ssa.Function) nilFact {
+	for inner := from; inner != fn; inner = inner.Parent() {
+		if inner.Parent() == nil {
+			return nilFact{Status: f.resolve()}
+		}
+		f = a.liftFact(f, inner)
+	}
+	return f
+}
+
+// liftFact instantiates the fact f, relative to the parameters and free
+// variables of the closure fn, in the function creating fn. Parameters are
+// only known when fn is called directly; free variables from its bindings.
+func (a *NilFlowAnalyzer) liftFact(f nilFact, fn *ssa.Function) nilFact {
+	if f.Params == 0 {
+		return f
+	}
+	if calls, ok := closureCalls(fn); ok && len(calls) > 0 {
+		out := nilFact{Status: nilStatusBottom}
+		for _, call := range calls {
+			out = out.join(a.instantiate(f, commonArgs(call.Common()), call))
+		}
+		return out
+	}
+	mc := closureSite(fn)
+	if mc == nil {
+		return nilFact{Status: NilStatusUnknown}
+	}
+	args := make([]ssa.Value, len(fn.Params), len(fn.Params)+len(mc.Bindings))
+	for _, i := range f.Params.indices() {
+		if i < len(fn.Params) {
+			return nilFact{Status: NilStatusUnknown}
+		}
+	}
+	return a.instantiate(f, append(args, mc.Bindings...), mc)
+}
@@ -0,0 +1,155 @@
+package analyzer
+
+import (
+	"flag"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Config holds user-tunable analyzer options. NewAnalyzerWithConfig binds
+// each option to a command-line flag on the returned analysis.Analyzer.
+type Config struct {
+	// DescriptorSet is the path to a serialized FileDescriptorSet (for example
+	// the output of `buf build -o`). When set, field policies are taken from
+	// the descriptors instead of struct-tag heuristics.
+	DescriptorSet string
+
+	// MaxNestedDepth bounds how many levels of sub-messages below the
+	// response are validated recursively.
+	MaxNestedDepth int
+
+	// BodilessDefault is the nil status assumed for callees whose body is
+	// not available: "unknown", "maybe" or "notnil".
+	BodilessDefault string
+	// FieldLoadDefault is the nil status assumed for fields loaded from
+	// values other than local allocations: "unknown", "maybe" or "notnil".
+	FieldLoadDefault string
+	// CallGraph selects how interface method and function value calls are
+	// resolved: "cha", "vta" or "none".
+	CallGraph string
+	// ExcludeImpls lists comma-separated package patterns (where "..."
+	// matches any string) whose functions are ignored as targets of dynamic
+	// calls, e.g. "...mocks" for generated test doubles.
+	ExcludeImpls string
+	// NoReturn lists comma-separated functions that never return, in the
+	// form of types.Func.FullName, e.g. "(*go.uber.org/zap.Logger).Fatal",
+	// in addition to built-ins such as os.Exit and log.Fatal.
+	NoReturn string
+
+	// DumpSchema names a message type whose classification tree is printed
+	// instead of running the analysis. DumpFormat selects "text" or "json".
+	DumpSchema string
+	DumpFormat string
+	// Output receives schema dumps; it defaults to os.Stdout.
+	Output io.Writer
+
+	descriptorOnce sync.Once
+	descriptors    *DescriptorIndex
+	descriptorErr  error
+
+	dumpMu sync.Mutex
+	dumped map[*types.TypeName]bool
+}
+
+// DefaultConfig returns the configuration used by NewAnalyzer.
+func DefaultConfig() *Config {
+	return &Config{
+		MaxNestedDepth:   5,
+		BodilessDefault:  "unknown",
+		FieldLoadDefault: "unknown",
+		CallGraph:        "cha",
+		DumpFormat:       "text",
+	}
+}
+
+// registerFlags binds the configuration fields to fs.
+func (c *Config) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.DescriptorSet, "descriptor-set", c.DescriptorSet,
+		"path to a FileDescriptorSet or buf image used to classify proto fields")
+	fs.IntVar(&c.MaxNestedDepth, "max-nested-depth", c.MaxNestedDepth,
+		"maximum depth of sub-messages below the response that are validated")
+	fs.StringVar(&c.BodilessDefault, "bodiless-default", c.BodilessDefault,
+		"nil status assumed for callees without a body: unknown, maybe or notnil")
+	fs.StringVar(&c.FieldLoadDefault, "field-load-default", c.FieldLoadDefault,
+		"nil status assumed for fields loaded from parameters, call results and other untracked values: unknown, maybe or notnil")
+	fs.StringVar(&c.CallGraph, "call-graph", c.CallGraph,
+		"call graph used to resolve interface and function value calls: cha, vta or none")
+	fs.StringVar(&c.ExcludeImpls, "exclude-impls", c.ExcludeImpls,
+		"comma-separated package patterns (... matches anything) whose functions are ignored as dynamic call targets")
+	fs.StringVar(&c.NoReturn, "no-return", c.NoReturn,
+		"comma-separated functions that never return, e.g. (*go.uber.org/zap.Logger).Fatal, besides os.Exit, log.Fatal and t.Fatal")
+	fs.StringVar(&c.DumpSchema, "dump-schema", c.DumpSchema,
+		"print the field classification tree of the named message type instead of analyzing")
+	fs.StringVar(&c.DumpFormat, "dump-format", c.DumpFormat,
+		"format of -dump-schema output: text or json")
+}
+
+// bodilessStatus parses BodilessDefault.
+func (c *Config) bodilessStatus() (NilStatus, error) {
+	return parseNilStatusFlag("bodiless-default", c.BodilessDefault)
+}
+
+// fieldLoadStatus parses FieldLoadDefault.
+func (c *Config) fieldLoadStatus() (NilStatus, error) {
+	return parseNilStatusFlag("field-load-default", c.FieldLoadDefault)
+}
+
+// noReturnNames parses NoReturn.
+func (c *Config) noReturnNames() []string {
+	var names []string
+	for _, name := range strings.Split(c.NoReturn, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseNilStatusFlag maps the flag spellings of a nil status to NilStatus.
+func parseNilStatusFlag(name, value string) (NilStatus, error) {
+	switch value {
+	case "unknown":
+		return NilStatusUnknown, nil
+	case "maybe":
+		return NilStatusMaybeNil, nil
+	case "notnil":
+		return NilStatusNotNil, nil
+	}
+	return NilStatusUnknown, fmt.Errorf("invalid -%s %q: want unknown, maybe or notnil", name, value)
+}
+
+// descriptorIndex loads the configured descriptor set once and shares it
+// between all packages analyzed in this process.
+func (c *Config) descriptorIndex() (*DescriptorIndex, error) {
+	if c.DescriptorSet == "" {
+		return nil, nil
+	}
+	c.descriptorOnce.Do(func() {
+		c.descriptors, c.descriptorErr = LoadDescriptorSet(c.DescriptorSet)
+	})
+	return c.descriptors, c.descriptorErr
+}
+
+// writeSchemaOnce prints the schema tree for the message declared by obj,
+// unless another package in this process already printed it.
+func (c *Config) writeSchemaOnce(obj *types.TypeName, node *SchemaNode) error {
+	c.dumpMu.Lock()
+	defer c.dumpMu.Unlock()
+	if c.dumped[obj] {
+		return nil
+	}
+	if c.dumped == nil {
+		c.dumped = make(map[*types.TypeName]bool)
+	}
+	c.dumped[obj] = true
+
+	out := c.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	return WriteSchema(out, node, c.DumpFormat)
+}
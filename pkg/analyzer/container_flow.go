@@ -0,0 +1,210 @@
+package analyzer
+
+import (
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// lookupFact computes the fact of the value of a map lookup that is not
+// known to hit: a missing key yields the zero value of the element type.
+func (a *NilFlowAnalyzer) lookupFact(l *ssa.Lookup) nilFact {
+	zero := zeroFact(lookupElemType(l))
+	if zero.Status == NilStatusNotNil {
+		return zero
+	}
+	return zero.join(a.elementFact(l.X))
+}
+
+// lookupElemType returns the type of the value found by l.
+func lookupElemType(l *ssa.Lookup) types.Type {
+	if m, ok := l.X.Type().Underlying().(*types.Map); ok {
+		return m.Elem()
+	}
+	// Indexing a string yields a byte.
+	return types.Typ[types.Byte]
+}
+
+// lookupMayMiss reports whether the value extracted from the map lookup v
+// may be the zero value of a missing key given that every branch in edges
+// was taken, i.e. v is not the result of a comma-ok lookup whose ok result
+// is known to be true.
+func lookupMayMiss(v ssa.Value, edges []edge) bool {
+	switch v := v.(type) {
+	case *ssa.Lookup:
+		return true
+	case *ssa.Extract:
+		l, ok := v.Tuple.(*ssa.Lookup)
+		if !ok || v.Index != 0 {
+			return false
+		}
+		for _, ref := range *l.Referrers() {
+			if okVal, isExt := ref.(*ssa.Extract); isExt && okVal.Index == 1 {
+				if holds, ok := edgesHold(okVal, edges); ok && holds {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// rangeValueFact computes the fact of the value produced by next, a step
+// of a range loop over a map.
+func (a *NilFlowAnalyzer) rangeValueFact(next *ssa.Next) nilFact {
+	r, ok := next.Iter.(*ssa.Range)
+	if !ok || next.IsString {
+		return nilFact{Status: NilStatusUnknown}
+	}
+	return a.elementFact(r.X)
+}
+
+// elementLoadFact computes the fact of the element, or the field at path
+// of the element, loaded through ia.
+func (a *NilFlowAnalyzer) elementLoadFact(ia *ssa.IndexAddr, path []int) nilFact {
+	if len(path) > 0 {
+		return a.untrackedLoadFact(ia, path)
+	}
+	if f := zeroFact(ia.Type().(*types.Pointer).Elem()); f.Status == NilStatusNotNil {
+		return f
+	}
+	return a.elementFact(ia.X)
+}
+
+// elementFact joins the facts of the elements of the slice, array pointer or
+// map v. Elements of containers created locally are traced to the stores
+// into them; containers of other origins use FieldLoadDefault.
+func (a *NilFlowAnalyzer) elementFact(v ssa.Value) nilFact {
+	switch v := v.(type) {
+	case *ssa.Const:
+		// A nil slice or map has no elements.
+		return nilFact{Status: nilStatusBottom}
+	case *ssa.Alloc, *ssa.MakeSlice, *ssa.MakeMap:
+		return a.containerFact(v)
+	case *ssa.Slice:
+		return a.elementFact(v.X)
+	case *ssa.Call:
+		if isBuiltin(v.Call, "append") {
+			return a.elementFact(v.Call.Args[0]).join(a.elementFact(v.Call.Args[1]))
+		}
+	case *ssa.Phi:
+		// Loops appending to a slice only add the elements of their other
+		// edges, so a Phi being traced contributes nothing.
+		if a.tracing[v] {
+			return nilFact{Status: nilStatusBottom}
+		}
+		a.tracing[v] = true
+		defer delete(a.tracing, v)
+		f := nilFact{Status: nilStatusBottom}
+		for _, edge := range v.Edges {
+			f = f.join(a.elementFact(edge))
+		}
+		return f
+	}
+	return nilFact{Status: a.FieldLoadDefault}
+}
+
+// containerFact joins the facts of the elements stored into the array,
+// slice or map created by base through any of its local aliases: slices of
+// it, Phis merging it and append results sharing its backing array.
+// Elements never stored are the zero value. The container is untracked if
+// an alias or element address is used in any other way.
+func (a *NilFlowAnalyzer) containerFact(base ssa.Value) nilFact {
+	elem, length := containerElem(base)
+	if elem == nil {
+		return nilFact{Status: a.FieldLoadDefault}
+	}
+	f := nilFact{Status: nilStatusBottom}
+	covered := make(map[int64]bool)
+	aliases := []ssa.Value{base}
+	seen := map[ssa.Value]bool{base: true}
+	alias := func(v ssa.Value) {
+		if !seen[v] {
+			seen[v] = true
+			aliases = append(aliases, v)
+		}
+	}
+	for i := 0; i < len(aliases); i++ {
+		for _, ref := range *aliases[i].Referrers() {
+			switch ref := ref.(type) {
+			case *ssa.IndexAddr:
+				idx, isConst := constIndex(ref.Index)
+				for _, use := range *ref.Referrers() {
+					switch use := use.(type) {
+					case *ssa.Store:
+						if use.Addr != ref {
+							return nilFact{Status: a.FieldLoadDefault}
+						}
+						f = f.join(a.factAt(use.Val, use))
+						if isConst {
+							covered[idx] = true
+						}
+					case *ssa.UnOp, *ssa.DebugRef:
+					default:
+						return nilFact{Status: a.FieldLoadDefault}
+					}
+				}
+			case *ssa.MapUpdate:
+				f = f.join(a.factAt(ref.Value, ref))
+			case *ssa.Slice, *ssa.Phi:
+				alias(ref.(ssa.Value))
+			case *ssa.Call:
+				switch {
+				case isBuiltin(ref.Call, "len"), isBuiltin(ref.Call, "cap"):
+				case isBuiltin(ref.Call, "append") && ref.Call.Args[0] == aliases[i]:
+					f = f.join(a.elementFact(ref.Call.Args[1]))
+					alias(ref)
+				case isBuiltin(ref.Call, "append"), isBuiltin(ref.Call, "copy") && ref.Call.Args[1] == aliases[i]:
+					// Elements read into another slice.
+				default:
+					return nilFact{Status: a.FieldLoadDefault}
+				}
+			case *ssa.Lookup, *ssa.Range, *ssa.DebugRef:
+			default:
+				return nilFact{Status: a.FieldLoadDefault}
+			}
+		}
+	}
+	if length < 0 || int64(len(covered)) < length {
+		f = f.join(zeroFact(elem))
+	}
+	return f
+}
+
+// containerElem returns the element type of the container created by base
+// and the number of zero elements it starts with, -1 if unknown. Maps start
+// empty; missing keys are accounted for by lookupFact.
+func containerElem(base ssa.Value) (types.Type, int64) {
+	switch base := base.(type) {
+	case *ssa.Alloc:
+		if arr, ok := base.Type().(*types.Pointer).Elem().Underlying().(*types.Array); ok {
+			return arr.Elem(), arr.Len()
+		}
+	case *ssa.MakeSlice:
+		length, ok := constIndex(base.Len)
+		if !ok {
+			length = -1
+		}
+		return base.Type().Underlying().(*types.Slice).Elem(), length
+	case *ssa.MakeMap:
+		return base.Type().Underlying().(*types.Map).Elem(), 0
+	}
+	return nil, 0
+}
+
+// constIndex returns the value of the integer constant v.
+func constIndex(v ssa.Value) (int64, bool) {
+	c, ok := v.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(c.Value)
+}
+
+// isBuiltin reports whether call calls the built-in function name.
+func isBuiltin(call ssa.CallCommon, name string) bool {
+	b, ok := call.Value.(*ssa.Builtin)
+	return ok && b.Name() == name
+}
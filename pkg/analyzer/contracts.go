@@ -0,0 +1,321 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Nullability contracts are stated with directives in the doc comment of a
+// function declaration:
+//
+//	//grpcnil:nonnil result
+//	//grpcnil:nullable param p
+//
+// or in the doc or line comment of a struct field:
+//
+//	Profile *Profile //grpcnil:nonnil
+//
+// The analysis trusts them instead of looking into the annotated code, and
+// verifies them where that code is analyzed.
+const directivePrefix = "//grpcnil:"
+
+// Contract is the nullability contract stated by directives on a function
+// or struct field.
+type Contract struct {
+	// Result is the declared status of a function's first result or of a
+	// field; NilStatusUnknown when none is declared.
+	Result NilStatus
+	// Params holds declared parameter statuses by index in
+	// ssa.Function.Params, so a method's receiver is param 0.
+	Params map[int]NilStatus
+	// ParamNames names the parameters in Params, for diagnostics.
+	ParamNames map[int]string
+}
+
+// directiveProblem is a malformed directive found by collectContracts.
+type directiveProblem struct {
+	pos token.Pos
+	msg string
+}
+
+// collectContracts parses the directives in files, keyed by the declared
+// *types.Func or field *types.Var.
+func collectContracts(files []*ast.File, info *types.Info) (map[types.Object]*Contract, []directiveProblem) {
+	contracts := make(map[types.Object]*Contract)
+	var problems []directiveProblem
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				obj, ok := info.Defs[n.Name].(*types.Func)
+				if !ok {
+					return true
+				}
+				c := &Contract{}
+				for _, d := range directives(n.Doc) {
+					if msg := c.applyFuncDirective(d, obj); msg != "" {
+						problems = append(problems, directiveProblem{d.Slash, msg})
+					}
+				}
+				if c.Result != NilStatusUnknown || len(c.Params) > 0 {
+					contracts[obj] = c
+				}
+			case *ast.StructType:
+				for _, field := range n.Fields.List {
+					ds := append(directives(field.Doc), directives(field.Comment)...)
+					if len(ds) == 0 {
+						continue
+					}
+					c := &Contract{}
+					for _, d := range ds {
+						status, target, msg := parseDirective(d)
+						if msg == "" && len(target) > 0 {
+							msg = "unexpected target " + strings.Join(target, " ") + " on a field"
+						}
+						if msg != "" {
+							problems = append(problems, directiveProblem{d.Slash, msg})
+							continue
+						}
+						c.Result = status
+					}
+					if c.Result == NilStatusUnknown {
+						continue
+					}
+					for _, name := range field.Names {
+						if obj := info.Defs[name]; obj != nil {
+							contracts[obj] = c
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return contracts, problems
+}
+
+// directives returns the grpcnil directives in doc.
+func directives(doc *ast.CommentGroup) []*ast.Comment {
+	if doc == nil {
+		return nil
+	}
+	var out []*ast.Comment
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, directivePrefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// parseDirective splits d into its status and target words.
+func parseDirective(d *ast.Comment) (NilStatus, []string, string) {
+	words := strings.Fields(strings.TrimPrefix(d.Text, directivePrefix))
+	if len(words) == 0 {
+		return NilStatusUnknown, nil, "empty grpcnil directive"
+	}
+	switch words[0] {
+	case "nonnil":
+		return NilStatusNotNil, words[1:], ""
+	case "nullable":
+		return NilStatusMaybeNil, words[1:], ""
+	}
+	return NilStatusUnknown, nil, "unknown grpcnil directive " + words[0]
+}
+
+// applyFuncDirective records the function directive d on fn in c and
+// returns a description of the problem if d is malformed.
+func (c *Contract) applyFuncDirective(d *ast.Comment, fn *types.Func) string {
+	status, target, msg := parseDirective(d)
+	if msg != "" {
+		return msg
+	}
+	sig := fn.Type().(*types.Signature)
+	switch {
+	case len(target) == 1 && target[0] == "result":
+		if sig.Results().Len() == 0 {
+			return fn.Name() + " has no result"
+		}
+		c.Result = status
+		return ""
+	case len(target) == 2 && target[0] == "param":
+		i := paramIndex(sig, target[1])
+		if i < 0 {
+			return fn.Name() + " has no parameter " + target[1]
+		}
+		if c.Params == nil {
+			c.Params = make(map[int]NilStatus)
+			c.ParamNames = make(map[int]string)
+		}
+		c.Params[i] = status
+		c.ParamNames[i] = target[1]
+		return ""
+	}
+	return "expected \"result\" or \"param <name>\" after the status"
+}
+
+// param returns the declared status of parameter i, if any. A nil Contract
+// declares nothing.
+func (c *Contract) param(i int) (NilStatus, bool) {
+	if c == nil {
+		return NilStatusUnknown, false
+	}
+	s, ok := c.Params[i]
+	return s, ok
+}
+
+// paramIndex returns the index of the parameter or receiver named name in
+// the parameters of the ssa.Function for sig, or -1.
+func paramIndex(sig *types.Signature, name string) int {
+	offset := 0
+	if recv := sig.Recv(); recv != nil {
+		if recv.Name() == name {
+			return 0
+		}
+		offset = 1
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		if sig.Params().At(i).Name() == name {
+			return offset + i
+		}
+	}
+	return -1
+}
+
+// contractOf returns the contract declared on fn, or nil.
+func (a *NilFlowAnalyzer) contractOf(fn *ssa.Function) *Contract {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+	if obj := fn.Object(); obj != nil {
+		return a.objectContract(obj)
+	}
+	return nil
+}
+
+// objectContract returns the contract declared on the function or field
+// obj, in the analyzed package or imported from its own, or nil.
+func (a *NilFlowAnalyzer) objectContract(obj types.Object) *Contract {
+	if c := a.Contracts[obj]; c != nil {
+		return c
+	}
+	if a.ImportContract != nil {
+		return a.ImportContract(obj)
+	}
+	return nil
+}
+
+// contractSummary returns the summary declared by fn's result contract, or
+// nil when fn declares none.
+func (a *NilFlowAnalyzer) contractSummary(fn *ssa.Function) *FuncSummary {
+	c := a.contractOf(fn)
+	if c == nil || c.Result == NilStatusUnknown {
+		return nil
+	}
+	return &FuncSummary{Result: c.Result, Source: SummaryFromContract}
+}
+
+// fieldContract returns the status declared on field number field of the
+// struct type t.
+func (a *NilFlowAnalyzer) fieldContract(t types.Type, field int) (NilStatus, bool) {
+	if t == nil {
+		return NilStatusUnknown, false
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok || field >= st.NumFields() {
+		return NilStatusUnknown, false
+	}
+	c := a.objectContract(st.Field(field))
+	if c == nil {
+		return NilStatusUnknown, false
+	}
+	return c.Result, true
+}
+
+// verifyContracts reports code in fns that breaks a non-nil contract: a
+// return of a maybe-nil value from a function declaring a non-nil result,
+// a store of one into a non-nil field, or a call passing one for a non-nil
+// parameter. Values of unknown status are trusted, since contracts exist
+// for code the analysis cannot see through.
+func verifyContracts(pass *analysis.Pass, a *NilFlowAnalyzer, fns []*ssa.Function) {
+	mayBeNil := func(v ssa.Value, instr ssa.Instruction) bool {
+		a.Reset()
+		s := a.StatusAt(v, instr)
+		return s == NilStatusMaybeNil || s == NilStatusDefinitelyNil
+	}
+	for _, fn := range fns {
+		c := a.contractOf(fn)
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Return:
+					if c == nil || c.Result != NilStatusNotNil || len(instr.Results) == 0 {
+						continue
+					}
+					if mayBeNil(instr.Results[0], instr) {
+						pass.Reportf(instr.Pos(), "broken nullability contract: %s is annotated //grpcnil:nonnil result but may return nil%s",
+							fn.Name(), reasonSuffix(a, instr.Results[0], instr))
+					}
+				case *ssa.Store:
+					fa, ok := instr.Addr.(*ssa.FieldAddr)
+					if !ok {
+						continue
+					}
+					owner := fa.X.Type().Underlying().(*types.Pointer).Elem()
+					if s, ok := a.fieldContract(owner, fa.Field); !ok || s != NilStatusNotNil {
+						continue
+					}
+					if mayBeNil(instr.Val, instr) {
+						field := owner.Underlying().(*types.Struct).Field(fa.Field)
+						pass.Reportf(instr.Pos(), "broken nullability contract: field %s.%s is annotated //grpcnil:nonnil but may be assigned nil%s",
+							typeName(owner), field.Name(), reasonSuffix(a, instr.Val, instr))
+					}
+				case *ssa.Call:
+					callee := instr.Call.StaticCallee()
+					if callee == nil || instr.Call.IsInvoke() {
+						continue
+					}
+					cc := a.contractOf(callee)
+					if cc == nil {
+						continue
+					}
+					args := callArgs(instr)
+					for i, s := range cc.Params {
+						if s == NilStatusNotNil && i < len(args) && mayBeNil(args[i], instr) {
+							pass.Reportf(instr.Pos(), "broken nullability contract: parameter %s of %s is annotated //grpcnil:nonnil but may be passed nil%s",
+								cc.ParamNames[i], callee.Name(), reasonSuffix(a, args[i], instr))
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// reasonSuffix formats the NilReason of v at instr as a diagnostic suffix.
+func reasonSuffix(a *NilFlowAnalyzer, v ssa.Value, instr ssa.Instruction) string {
+	if reason := a.NilReason(v, instr); reason != "" {
+		return "; " + reason
+	}
+	return ""
+}
+
+// typeName returns the unqualified name of t.
+func typeName(t types.Type) string {
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return t.String()
+}
+
+// reportDirectiveProblems reports malformed directives.
+func reportDirectiveProblems(pass *analysis.Pass, problems []directiveProblem) {
+	for _, p := range problems {
+		pass.Reportf(p.pos, "malformed directive: %s", p.msg)
+	}
+}
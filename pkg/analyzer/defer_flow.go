@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"golang.org/x/tools/go/ssa"
+)
+
+// Deferred calls run at the RunDefers instruction preceding each return,
+// in reverse order of their defer statements. A deferred closure that
+// patches a named result,
+//
+//	defer func() {
+//		if p == nil {
+//			p = &P{}
+//		}
+//	}()
+//
+// is therefore a definition of p at RunDefers, and the value seen by the
+// caller is the value p holds when the closure returns.
+
+// runSites returns the instructions at which the closure launched by instr
+// runs: the RunDefers instructions of its function for a defer statement,
+// and instr itself otherwise.
+func runSites(instr ssa.Instruction) []ssa.Instruction {
+	d, ok := instr.(*ssa.Defer)
+	if !ok {
+		return []ssa.Instruction{instr}
+	}
+	var sites []ssa.Instruction
+	for _, b := range d.Parent().Blocks {
+		for _, instr := range b.Instrs {
+			if rd, ok := instr.(*ssa.RunDefers); ok {
+				sites = append(sites, rd)
+			}
+		}
+	}
+	return sites
+}
+
+// deferredCalls returns the defer statements of fn in block order.
+func deferredCalls(fn *ssa.Function) []*ssa.Defer {
+	var defers []*ssa.Defer
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if d, ok := instr.(*ssa.Defer); ok {
+				defers = append(defers, d)
+			}
+		}
+	}
+	return defers
+}
+
+// runsOnExit reports whether the defer statement d is executed on every
+// path to a normal return of its function.
+func runsOnExit(d *ssa.Defer) bool {
+	for _, site := range runSites(d) {
+		if !d.Block().Dominates(site.Block()) {
+			return false
+		}
+	}
+	return true
+}
+
+// deferredStores returns the stores through which the closures deferred in
+// the function of rd write the field at path of the variable cell.
+func deferredStores(rd *ssa.RunDefers, cell ssa.Value, path []int) []*ssa.Store {
+	var stores []*ssa.Store
+	for _, d := range deferredCalls(rd.Parent()) {
+		s, _ := capturedStores(d, cell, path)
+		stores = append(stores, s...)
+	}
+	return stores
+}
+
+// deferredStoreFact computes the fact of the field at path of alloc after
+// rd has run the deferred closures writing it. Each closure starts from the
+// value left by the closures deferred after it; one whose defer statement
+// may be skipped leaves that value possible as well.
+func (a *NilFlowAnalyzer) deferredStoreFact(rd *ssa.RunDefers, alloc *ssa.Alloc, path []int) nilFact {
+	if a.tracing[rd] {
+		return nilFact{Status: nilStatusBottom}
+	}
+	a.tracing[rd] = true
+	defer delete(a.tracing, rd)
+
+	f := a.fieldLoadFact(alloc, path, rd)
+	defers := deferredCalls(rd.Parent())
+	for i := len(defers) - 1; i >= 0; i-- {
+		d := defers[i]
+		if stores, _ := capturedStores(d, alloc, path); len(stores) == 0 {
+			continue
+		}
+		g := a.closureExitFact(d, alloc, path, f)
+		if d.Block().Dominates(rd.Block()) {
+			f = g
+		} else {
+			f = f.join(g)
+		}
+	}
+	return f
+}
+
+// closureExitFact computes the fact of the field at path of the variable
+// cell when the closure launched by call returns, given before, its fact
+// when the closure starts. Paths through the closure that neither store
+// the field nor check it against nil keep before.
+func (a *NilFlowAnalyzer) closureExitFact(call ssa.Instruction, cell ssa.Value, path []int, before nilFact) nilFact {
+	mc, _ := launchedClosure(call)
+	fn := mc.Fn.(*ssa.Function)
+	args := closureArgs(call)
+	f := nilFact{Status: nilStatusBottom}
+	for i, binding := range mc.Bindings {
+		if binding != cell {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			if len(b.Instrs) == 0 || a.isDead(b) {
+				continue
+			}
+			ret, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return)
+			if !ok {
+				continue
+			}
+			defs, checked, open := reachingFieldDefs(fn.FreeVars[i], path, ret)
+			if open {
+				f = f.join(before)
+			}
+			for _, s := range checked {
+				f = f.join(nilFact{Status: s})
+			}
+			for _, def := range defs {
+				store, ok := def.(*ssa.Store)
+				if !ok {
+					// A nested closure writes the field.
+					f = f.join(nilFact{Status: a.FieldLoadDefault})
+					continue
+				}
+				if _, prefix := fieldAddrPath(store.Addr); len(prefix) != len(path) {
+					f = f.join(nilFact{Status: a.FieldLoadDefault})
+					continue
+				}
+				f = f.join(a.instantiate(a.factAt(store.Val, store), args, call))
+			}
+		}
+	}
+	return f
+}
+
+// mayRecover reports whether a call deferred by fn may recover a panic,
+// resuming fn at its Recover block: a deferred function that calls recover
+// directly, or one that is not known statically.
+func mayRecover(fn *ssa.Function) bool {
+	for _, d := range deferredCalls(fn) {
+		callee := d.Call.StaticCallee()
+		if callee == nil {
+			return true
+		}
+		for _, b := range callee.Blocks {
+			for _, instr := range b.Instrs {
+				if call, ok := instr.(*ssa.Call); ok && isBuiltin(call.Call, "recover") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// DescriptorIndex resolves Go message types to the proto message definitions
+// contained in a FileDescriptorSet.
+type DescriptorIndex struct {
+	messages  map[string]*MessageDescriptor   // keyed by proto full name
+	byGoName  map[string]*MessageDescriptor   // keyed by "<go import path>.<Go type name>"
+	byGoIdent map[string][]*MessageDescriptor // keyed by Go type name only
+}
+
+// MessageDescriptor is the subset of a proto message definition used to
+// classify the fields of its generated Go struct.
+type MessageDescriptor struct {
+	FullName string
+	proto    *descriptorpb.DescriptorProto
+	index    *DescriptorIndex
+}
+
+// LoadDescriptorSet reads a binary FileDescriptorSet from path. Buf images are
+// wire-compatible with FileDescriptorSet, so `buf build -o` output is accepted
+// as well; buf-specific extensions are discarded.
+func LoadDescriptorSet(path string) (*DescriptorIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading descriptor set: %w", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := (proto.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("parsing descriptor set %s: %w", path, err)
+	}
+	return NewDescriptorIndex(set), nil
+}
+
+// NewDescriptorIndex indexes all (nested) messages of set.
+func NewDescriptorIndex(set *descriptorpb.FileDescriptorSet) *DescriptorIndex {
+	idx := &DescriptorIndex{
+		messages:  make(map[string]*MessageDescriptor),
+		byGoName:  make(map[string]*MessageDescriptor),
+		byGoIdent: make(map[string][]*MessageDescriptor),
+	}
+	for _, file := range set.GetFile() {
+		goPkg := goImportPath(file)
+		for _, msg := range file.GetMessageType() {
+			idx.addMessage(goPkg, file.GetPackage(), "", msg)
+		}
+	}
+	return idx
+}
+
+func (d *DescriptorIndex) addMessage(goPkg, protoPkg, parent string, msg *descriptorpb.DescriptorProto) {
+	relName := msg.GetName()
+	if parent != "" {
+		relName = parent + "." + relName
+	}
+	fullName := relName
+	if protoPkg != "" {
+		fullName = protoPkg + "." + relName
+	}
+
+	md := &MessageDescriptor{FullName: fullName, proto: msg, index: d}
+	d.messages[fullName] = md
+
+	// Map entries never surface as Go types, but they are still needed to
+	// recognize map fields.
+	if !msg.GetOptions().GetMapEntry() {
+		goName := goCamelCase(relName)
+		if goPkg != "" {
+			d.byGoName[goPkg+"."+goName] = md
+		}
+		d.byGoIdent[goName] = append(d.byGoIdent[goName], md)
+	}
+
+	for _, nested := range msg.GetNestedType() {
+		d.addMessage(goPkg, protoPkg, relName, nested)
+	}
+}
+
+// Lookup returns the descriptor of the proto message that named was generated
+// from. Files without a go_package option are matched by Go type name alone
+// when that name is unambiguous.
+func (d *DescriptorIndex) Lookup(named *types.Named) *MessageDescriptor {
+	if d == nil || named == nil {
+		return nil
+	}
+	obj := named.Obj()
+	if obj.Pkg() != nil {
+		if md, ok := d.byGoName[obj.Pkg().Path()+"."+obj.Name()]; ok {
+			return md
+		}
+	}
+	if candidates := d.byGoIdent[obj.Name()]; len(candidates) == 1 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// Field returns the field descriptor with the given field number.
+func (m *MessageDescriptor) Field(number int32) *descriptorpb.FieldDescriptorProto {
+	for _, fd := range m.proto.GetField() {
+		if fd.GetNumber() == number {
+			return fd
+		}
+	}
+	return nil
+}
+
+// classifyField derives a field's risk from its descriptor. The second result
+// reports whether the field is explicitly optional (oneof member or proto3
+// optional).
+func (m *MessageDescriptor) classifyField(fd *descriptorpb.FieldDescriptorProto) (FieldRisk, bool) {
+	optional := fd.OneofIndex != nil || fd.GetProto3Optional()
+	if fd.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		return FieldRiskSafe, optional
+	}
+	if fd.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		if elem := m.index.messages[strings.TrimPrefix(fd.GetTypeName(), ".")]; elem != nil && elem.proto.GetOptions().GetMapEntry() {
+			return FieldRiskSafe, optional
+		}
+		return FieldRiskRepeatedMessagePointer, optional
+	}
+	if optional {
+		return FieldRiskSafe, optional
+	}
+	return FieldRiskMessagePointer, optional
+}
+
+// goImportPath extracts the import path from a file's go_package option,
+// e.g. "example.com/foo/v1;foopb" yields "example.com/foo/v1".
+func goImportPath(file *descriptorpb.FileDescriptorProto) string {
+	goPkg := file.GetOptions().GetGoPackage()
+	if i := strings.IndexByte(goPkg, ';'); i >= 0 {
+		goPkg = goPkg[:i]
+	}
+	return goPkg
+}
+
+// protoFieldNumber parses the field number out of a generated struct tag such
+// as `protobuf:"bytes,1,opt,name=profile,proto3"`.
+func protoFieldNumber(tag string) (int32, bool) {
+	parts := strings.Split(reflectTagValue(tag, "protobuf"), ",")
+	if len(parts) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int32(n), true
+}
+
+// goCamelCase mirrors protoc-gen-go's identifier mangling so that proto
+// message names can be matched against generated Go type names. Nested
+// messages ("Outer.Inner") map to "Outer_Inner".
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '.' in ".{{lowercase}}".
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '_' in "_{{lowercase}}".
+		case isASCIIDigit(c):
+			b = append(b, c)
+		default:
+			if isASCIILower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool { return 'a' <= c && c <= 'z' }
+func isASCIIDigit(c byte) bool { return '0' <= c && c <= '9' }
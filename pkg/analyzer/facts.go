@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// summaryFact exports the FuncSummary of a function to the packages
+// importing it, so that modular drivers such as go vet -vettool see the
+// same summaries as a whole-program run.
+type summaryFact struct {
+	Summary FuncSummary
+}
+
+func (*summaryFact) AFact() {}
+
+func (f *summaryFact) String() string {
+	return "summary " + f.Summary.String()
+}
+
+// exportSummaries exports the summaries of the exported functions and
+// methods in fns that may return nil, assign message fields or never
+// return. Handlers are called by the gRPC runtime rather than by other
+// packages and are skipped.
+func exportSummaries(pass *analysis.Pass, a *NilFlowAnalyzer, fns []*ssa.Function) {
+	for _, fn := range fns {
+		obj, ok := fn.Object().(*types.Func)
+		if !ok || !obj.Exported() || fn.Parent() != nil || obj.Pkg() != pass.Pkg || isGeneric(obj) {
+			continue
+		}
+		if DetectHandlerFromFunc(fn) != nil {
+			continue
+		}
+		s := a.Summary(fn)
+		results := fn.Signature.Results()
+		nillable := results.Len() > 0 && zeroFact(results.At(0).Type()).Status == NilStatusDefinitelyNil
+		if s.Source == SummaryNotAnalyzed || !nillable && len(s.Sets) == 0 && !s.NoReturn {
+			continue
+		}
+		pass.ExportObjectFact(obj, &summaryFact{Summary: *s})
+	}
+}
+
+// summaryImporter returns a function looking up the summary exported for a
+// function of another package.
+func summaryImporter(pass *analysis.Pass) func(*ssa.Function) *FuncSummary {
+	return func(fn *ssa.Function) *FuncSummary {
+		obj, ok := fn.Object().(*types.Func)
+		if !ok || obj.Pkg() == pass.Pkg {
+			return nil
+		}
+		var fact summaryFact
+		if !pass.ImportObjectFact(obj, &fact) {
+			return nil
+		}
+		return &fact.Summary
+	}
+}
+
+// contractFact exports the Contract declared on an exported function or
+// struct field, so that packages calling the function or storing into the
+// field verify and trust it like their own.
+type contractFact struct {
+	Contract Contract
+	Field    bool
+}
+
+func (*contractFact) AFact() {}
+
+func (f *contractFact) String() string {
+	var parts []string
+	switch {
+	case f.Field:
+		parts = append(parts, directiveWord(f.Contract.Result))
+	case f.Contract.Result != NilStatusUnknown:
+		parts = append(parts, directiveWord(f.Contract.Result)+" result")
+	}
+	params := make([]int, 0, len(f.Contract.Params))
+	for i := range f.Contract.Params {
+		params = append(params, i)
+	}
+	sort.Ints(params)
+	for _, i := range params {
+		parts = append(parts, directiveWord(f.Contract.Params[i])+" param "+f.Contract.ParamNames[i])
+	}
+	return "contract " + strings.Join(parts, "; ")
+}
+
+// directiveWord returns the directive word declaring status.
+func directiveWord(status NilStatus) string {
+	if status == NilStatusNotNil {
+		return "nonnil"
+	}
+	return "nullable"
+}
+
+// exportContracts exports the contracts declared on exported objects of
+// the package of pass.
+func exportContracts(pass *analysis.Pass, contracts map[types.Object]*Contract) {
+	for obj, c := range contracts {
+		if obj.Exported() && obj.Pkg() == pass.Pkg {
+			_, isField := obj.(*types.Var)
+			pass.ExportObjectFact(obj, &contractFact{Contract: *c, Field: isField})
+		}
+	}
+}
+
+// contractImporter returns a function looking up the contract exported for
+// a function or field of another package.
+func contractImporter(pass *analysis.Pass) func(types.Object) *Contract {
+	return func(obj types.Object) *Contract {
+		if obj.Pkg() == nil || obj.Pkg() == pass.Pkg {
+			return nil
+		}
+		var fact contractFact
+		if !pass.ImportObjectFact(obj, &fact) {
+			return nil
+		}
+		return &fact.Contract
+	}
+}
+
+// requirementsFact exports the implicit requirements mined from the
+// consumer code of a package, such as generated clients and the helpers
+// wrapping them, to the packages importing it. Facts only flow along
+// imports, so consumers that the server package does not import are
+// never seen.
+type requirementsFact struct {
+	Fields []requiredField
+}
+
+func (*requirementsFact) AFact() {}
+
+func (f *requirementsFact) String() string {
+	parts := make([]string, len(f.Fields))
+	for i, rf := range f.Fields {
+		parts[i] = rf.Message[strings.LastIndex(rf.Message, ".")+1:] + "." + rf.Field + " at " + rf.Site
+	}
+	return "requires " + strings.Join(parts, ", ")
+}
+
+// exportRequirements exports the requirements mined in the package of pass.
+func exportRequirements(pass *analysis.Pass, fields []requiredField) {
+	if len(fields) > 0 {
+		pass.ExportPackageFact(&requirementsFact{Fields: fields})
+	}
+}
+
+// importRequirements marks the requirements mined in the packages pass
+// imports, directly or indirectly, on protoAnalyzer.
+func importRequirements(pass *analysis.Pass, protoAnalyzer *ProtoFieldAnalyzer) {
+	for _, pf := range pass.AllPackageFacts() {
+		fact, ok := pf.Fact.(*requirementsFact)
+		if !ok || pf.Package == pass.Pkg {
+			continue
+		}
+		for _, rf := range fact.Fields {
+			protoAnalyzer.markRequirement(rf.Message, rf.Field, rf.Site)
+		}
+	}
+}
+
+// isGeneric reports whether fn has type parameters of its own or of its
+// receiver type.
+func isGeneric(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	return sig.TypeParams().Len() > 0 || sig.RecvTypeParams().Len() > 0
+}
@@ -0,0 +1,579 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// fieldLoadFact computes the fact of the field reached by the field path
+// from the variable or struct at addr, loaded at the program point at.
+// Function-local allocations whose address does not escape are tracked
+// precisely: the stores reaching at, including those made by closures
+// capturing the variable, are joined, and a path without a store
+// contributes the field's zero value. Loads of nillable values from any
+// other base are FieldLoadDefault.
+func (a *NilFlowAnalyzer) fieldLoadFact(addr ssa.Value, path []int, at ssa.Instruction) nilFact {
+	root, prefix := fieldAddrPath(addr)
+	path = append(prefix, path...)
+	if fv, ok := root.(*ssa.FreeVar); ok {
+		if f, ok := a.capturedLoadFact(fv, path); ok {
+			return f
+		}
+	}
+	if ia, ok := root.(*ssa.IndexAddr); ok {
+		return a.elementLoadFact(ia, path)
+	}
+	alloc, ok := root.(*ssa.Alloc)
+	if !ok || addrEscapes(alloc) {
+		return a.untrackedLoadFact(root, path)
+	}
+	return a.trackedFieldFact(alloc, path, at)
+}
+
+// untrackedLoadFact computes the fact of the field at path of a base whose
+// stores cannot be followed locally. Package variables and fields of the
+// package's own types may be proven non-nil from all stores into them.
+func (a *NilFlowAnalyzer) untrackedLoadFact(root ssa.Value, path []int) nilFact {
+	if f := zeroFact(fieldPathType(root.Type(), path)); f.Status == NilStatusNotNil {
+		// Struct and scalar values are never nil.
+		return f
+	}
+	if g, ok := root.(*ssa.Global); ok && len(path) == 0 {
+		return nilFact{Status: a.globalStatus(g)}
+	}
+	if len(path) > 0 {
+		ownerType := fieldPathType(root.Type(), path[:len(path)-1])
+		if s, ok := a.fieldContract(ownerType, path[len(path)-1]); ok {
+			return nilFact{Status: s}
+		}
+		owner, _ := ownerType.(*types.Named)
+		if owner != nil && a.fieldStatus(owner, path[len(path)-1]) == NilStatusNotNil {
+			return nilFact{Status: NilStatusNotNil}
+		}
+	}
+	return nilFact{Status: a.FieldLoadDefault}
+}
+
+// trackedFieldFact computes the fact of the field at path of alloc at the
+// program point at from the definitions reaching it.
+func (a *NilFlowAnalyzer) trackedFieldFact(alloc *ssa.Alloc, path []int, at ssa.Instruction) nilFact {
+	defs, checked, _ := reachingFieldDefs(alloc, path, at)
+	if len(defs) == 0 && len(checked) == 0 {
+		return nilFact{Status: a.FieldLoadDefault}
+	}
+
+	f := nilFact{Status: nilStatusBottom}
+	for _, s := range checked {
+		f = f.join(nilFact{Status: s})
+	}
+	for _, def := range defs {
+		switch def := def.(type) {
+		case *ssa.Alloc:
+			f = f.join(zeroFact(fieldPathType(def.Type(), path)))
+		case *ssa.Store:
+			_, prefix := fieldAddrPath(def.Addr)
+			rest := path[len(prefix):]
+			if len(rest) == 0 {
+				f = f.join(a.factAt(def.Val, def))
+			} else if load, ok := def.Val.(*ssa.UnOp); ok && load.Op == token.MUL && !a.tracing[load] {
+				// An enclosing struct was overwritten with a copy of
+				// another variable; continue in that variable.
+				a.tracing[load] = true
+				f = f.join(a.fieldLoadFact(load.X, rest, load))
+				delete(a.tracing, load)
+			} else {
+				f = f.join(nilFact{Status: a.FieldLoadDefault})
+			}
+		case *ssa.Call, *ssa.Go:
+			f = f.join(a.closureStoreFact(def, alloc, path))
+		case *ssa.RunDefers:
+			f = f.join(a.deferredStoreFact(def, alloc, path))
+		}
+	}
+	return f
+}
+
+// capturedLoadFact computes the fact of the field at path of a variable
+// captured by reference by a closure, joining its value wherever the
+// closure runs (see launchedClosure and runSites). It fails unless the closure
+// is created once, only called or started, and the variable is a tracked
+// local allocation. Values depending on the parameters of the enclosing
+// function are unknown in the closure.
+func (a *NilFlowAnalyzer) capturedLoadFact(fv *ssa.FreeVar, path []int) (nilFact, bool) {
+	fn := fv.Parent()
+	mc := closureSite(fn)
+	if mc == nil {
+		return nilFact{}, false
+	}
+	var alloc *ssa.Alloc
+	for i, v := range fn.FreeVars {
+		if v == fv {
+			alloc, _ = mc.Bindings[i].(*ssa.Alloc)
+		}
+	}
+	if alloc == nil || addrEscapes(alloc) {
+		return nilFact{}, false
+	}
+
+	f := nilFact{Status: nilStatusBottom}
+	for _, ref := range *mc.Referrers() {
+		if launched, _ := launchedClosure(ref); launched != mc {
+			continue
+		}
+		for _, site := range runSites(ref) {
+			if !a.tracing[site] {
+				a.tracing[site] = true
+				f = f.join(a.fieldLoadFact(alloc, path, site))
+				delete(a.tracing, site)
+			}
+		}
+	}
+	if f.Params != 0 {
+		f = nilFact{Status: joinNilStatus(f.Status, NilStatusUnknown)}
+	}
+	return f, true
+}
+
+// closureSite returns the only MakeClosure creating fn, or nil.
+func closureSite(fn *ssa.Function) *ssa.MakeClosure {
+	parent := fn.Parent()
+	if parent == nil {
+		return nil
+	}
+	var site *ssa.MakeClosure
+	for _, b := range parent.Blocks {
+		for _, instr := range b.Instrs {
+			if mc, ok := instr.(*ssa.MakeClosure); ok && mc.Fn == fn {
+				if site != nil {
+					return nil
+				}
+				site = mc
+			}
+		}
+	}
+	return site
+}
+
+// closureStoreFact computes the fact of the field at path of alloc after
+// call invokes or starts a closure that captures alloc and writes the field.
+// Unless the closure is called and writes it on every path to a return, the
+// value from before the call may survive.
+func (a *NilFlowAnalyzer) closureStoreFact(call ssa.Instruction, alloc *ssa.Alloc, path []int) nilFact {
+	if a.tracing[call] {
+		// A loop around the call; its values are already being joined.
+		return nilFact{Status: nilStatusBottom}
+	}
+	a.tracing[call] = true
+	defer delete(a.tracing, call)
+
+	stores, must := capturedStores(call, alloc, path)
+	args := closureArgs(call)
+	f := nilFact{Status: nilStatusBottom}
+	for _, store := range stores {
+		if _, prefix := fieldAddrPath(store.Addr); len(prefix) == len(path) {
+			f = f.join(a.instantiate(a.factAt(store.Val, store), args, call))
+		} else {
+			f = f.join(nilFact{Status: a.FieldLoadDefault})
+		}
+	}
+	if !must {
+		f = f.join(a.fieldLoadFact(alloc, path, call))
+	}
+	return f
+}
+
+// capturedStores returns the stores through which the closure invoked or
+// started by call writes the field at path of the variable cell, and
+// whether one of them happens on every path to a return of a closure that
+// is called synchronously. Stores made by nested closures are included but
+// never count as certain.
+func capturedStores(call ssa.Instruction, cell ssa.Value, path []int) ([]*ssa.Store, bool) {
+	mc, async := launchedClosure(call)
+	if mc == nil {
+		return nil, false
+	}
+	fn := mc.Fn.(*ssa.Function)
+	var (
+		stores []*ssa.Store
+		must   bool
+	)
+	for i, binding := range mc.Bindings {
+		if binding != cell {
+			continue
+		}
+		fv := fn.FreeVars[i]
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Store:
+					root, prefix := fieldAddrPath(instr.Addr)
+					if root == fv && isPathPrefix(prefix, path) {
+						stores = append(stores, instr)
+						must = must || (!async && dominatesReturns(b))
+					}
+				case *ssa.Call, *ssa.Go:
+					nested, _ := capturedStores(instr, fv, path)
+					stores = append(stores, nested...)
+				}
+			}
+		}
+	}
+	return stores, must
+}
+
+// dominatesReturns reports whether b dominates every returning block of its
+// function.
+func dominatesReturns(b *ssa.BasicBlock) bool {
+	for _, other := range b.Parent().Blocks {
+		if len(other.Instrs) == 0 {
+			continue
+		}
+		if _, ok := other.Instrs[len(other.Instrs)-1].(*ssa.Return); ok && !b.Dominates(other) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldAddrPath splits addr into its root value and the field path applied
+// to it by a chain of FieldAddr instructions.
+func fieldAddrPath(addr ssa.Value) (ssa.Value, []int) {
+	var path []int
+	for {
+		fa, ok := addr.(*ssa.FieldAddr)
+		if !ok {
+			return addr, path
+		}
+		path = append([]int{fa.Field}, path...)
+		addr = fa.X
+	}
+}
+
+// fieldPathType returns the type of the field reached by path from the
+// value pointed to by ptr, or nil if it cannot be determined, e.g. for
+// type parameters.
+func fieldPathType(ptr types.Type, path []int) types.Type {
+	p, ok := ptr.Underlying().(*types.Pointer)
+	if !ok {
+		return nil
+	}
+	t := p.Elem()
+	for _, i := range path {
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return nil
+		}
+		t = st.Field(i).Type()
+	}
+	return t
+}
+
+// structFieldFact computes the fact of a field of a struct value, tracing
+// chains of Field instructions back to the variable the struct was loaded
+// from.
+func (a *NilFlowAnalyzer) structFieldFact(field *ssa.Field) nilFact {
+	path := []int{field.Field}
+	x := field.X
+	for {
+		inner, ok := x.(*ssa.Field)
+		if !ok {
+			break
+		}
+		path = append([]int{inner.Field}, path...)
+		x = inner.X
+	}
+	if load, ok := x.(*ssa.UnOp); ok && load.Op == token.MUL {
+		return a.fieldLoadFact(load.X, path, load)
+	}
+	if s, ok := a.fieldContract(field.X.Type(), field.Field); ok {
+		return nilFact{Status: s}
+	}
+	return nilFact{Status: a.FieldLoadDefault}
+}
+
+// reachingFieldDefs returns the instructions whose effect on the field at
+// path in root, a local allocation or a captured variable, may be observed
+// at at: stores to the field or to an enclosing struct, calls and deferred
+// calls of closures storing to it, and the allocation itself, which zeroes
+// the field. Paths entering at through a nil check of a load of the field,
+// with no definition in between, contribute the checked status instead.
+// open reports whether a path from the function's entry reaches at without
+// any of them.
+func reachingFieldDefs(root ssa.Value, path []int, at ssa.Instruction) (defs []ssa.Instruction, checked []NilStatus, open bool) {
+	isDef := func(instr ssa.Instruction) bool {
+		switch instr := instr.(type) {
+		case *ssa.Alloc:
+			return instr == root
+		case *ssa.Store:
+			r, prefix := fieldAddrPath(instr.Addr)
+			return r == root && isPathPrefix(prefix, path)
+		case *ssa.Call, *ssa.Go:
+			stores, _ := capturedStores(instr, root, path)
+			return len(stores) > 0
+		case *ssa.RunDefers:
+			return len(deferredStores(instr, root, path)) > 0
+		}
+		return false
+	}
+	lastDef := func(instrs []ssa.Instruction) ssa.Instruction {
+		for i := len(instrs) - 1; i >= 0; i-- {
+			if isDef(instrs[i]) {
+				return instrs[i]
+			}
+		}
+		return nil
+	}
+
+	b := at.Block()
+	for i, instr := range b.Instrs {
+		if instr == at {
+			if def := lastDef(b.Instrs[:i]); def != nil {
+				return []ssa.Instruction{def}, nil, false
+			}
+			break
+		}
+	}
+
+	// checkedLoad reports the status of the field on the edge from pred to
+	// succ when pred branches on a nil check of a load of the field made
+	// after pred's last definition.
+	checkedLoad := func(pred, succ *ssa.BasicBlock) (NilStatus, bool) {
+		ifInstr := branchIf(pred)
+		if ifInstr == nil {
+			return NilStatusUnknown, false
+		}
+		x, _, ok := nilComparison(ifInstr.Cond)
+		load, isLoad := x.(*ssa.UnOp)
+		if !ok || !isLoad || load.Op != token.MUL || load.Block() != pred {
+			return NilStatusUnknown, false
+		}
+		r, prefix := fieldAddrPath(load.X)
+		if r != root || len(prefix) != len(path) || !isPathPrefix(prefix, path) {
+			return NilStatusUnknown, false
+		}
+		for i := len(pred.Instrs) - 1; pred.Instrs[i] != load; i-- {
+			if isDef(pred.Instrs[i]) {
+				return NilStatusUnknown, false
+			}
+		}
+		return branchStatus(load, pred, succ)
+	}
+
+	// Search every path backwards for its last definition.
+	seen := make(map[*ssa.BasicBlock]bool)
+	var walk func(b *ssa.BasicBlock)
+	walk = func(b *ssa.BasicBlock) {
+		if b == b.Parent().Blocks[0] {
+			open = true
+		}
+		for _, pred := range b.Preds {
+			if s, ok := checkedLoad(pred, b); ok {
+				checked = append(checked, s)
+				continue
+			}
+			if seen[pred] {
+				continue
+			}
+			seen[pred] = true
+			if def := lastDef(pred.Instrs); def != nil {
+				defs = append(defs, def)
+			} else {
+				walk(pred)
+			}
+		}
+	}
+	walk(b)
+	return defs, checked, open
+}
+
+// addrEscapes reports whether the address v of a local variable, or the
+// address of one of its fields, is used other than to load and store fields
+// directly or by closures that are only called, in which case its fields
+// may be written out of sight.
+func addrEscapes(v ssa.Value) bool {
+	return addrEscapesFrom(v, make(map[ssa.Value]bool))
+}
+
+// addrEscapesFrom implements addrEscapes; seen holds the variables whose
+// copies are being followed, so that cyclic structures terminate.
+func addrEscapesFrom(v ssa.Value, seen map[ssa.Value]bool) bool {
+	if seen[v] {
+		return true
+	}
+	seen[v] = true
+	defer delete(seen, v)
+
+	for _, ref := range *v.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef:
+		case *ssa.UnOp:
+			if ref.Op != token.MUL {
+				return true
+			}
+		case *ssa.Store:
+			// Only a store through v defines its value; storing v itself
+			// makes a copy that may be used to write its fields.
+			if ref.Val == v && copyEscapes(ref, seen) {
+				return true
+			}
+		case *ssa.FieldAddr:
+			if fieldAddrEscapes(ref) {
+				return true
+			}
+		case *ssa.Call:
+			// Getters only read the receiver.
+			if !isGetterCall(ref) {
+				return true
+			}
+		case *ssa.MakeClosure:
+			if closureEscapes(ref, v) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// copyEscapes reports whether the pointer that store copies into a field of
+// another local variable may be used to write the fields it points to: the
+// variable escapes, or the copy is loaded from it other than to read
+// through it, e.g. h := &Holder{U: u}; h.U.Profile = nil.
+func copyEscapes(store *ssa.Store, seen map[ssa.Value]bool) bool {
+	root, path := fieldAddrPath(store.Addr)
+	alloc, ok := root.(*ssa.Alloc)
+	if !ok || len(path) == 0 || addrEscapesFrom(alloc, seen) {
+		return true
+	}
+	var copies []ssa.Value
+	var visit func(v ssa.Value, prefix []int) bool
+	visit = func(v ssa.Value, prefix []int) bool {
+		for _, ref := range *v.Referrers() {
+			switch ref := ref.(type) {
+			case *ssa.FieldAddr:
+				if p := append(append([]int(nil), prefix...), ref.Field); isPathPrefix(p, path) && visit(ref, p) {
+					return true
+				}
+			case *ssa.UnOp:
+				if len(prefix) < len(path) {
+					// A copy of a struct value holding the field.
+					return true
+				}
+				copies = append(copies, ref)
+			case *ssa.Call:
+				if i, ok := getterFieldIndex(ref); ok && len(prefix) == 0 && len(path) == 1 && i == path[0] {
+					copies = append(copies, ref)
+				}
+			case *ssa.MakeClosure:
+				return true
+			}
+		}
+		return false
+	}
+	if visit(alloc, nil) {
+		return true
+	}
+	for _, c := range copies {
+		if !readOnly(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnly reports whether the pointer v is only used to read through it:
+// loads, field loads, getter calls and comparisons.
+func readOnly(v ssa.Value) bool {
+	for _, ref := range *v.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef, *ssa.BinOp:
+		case *ssa.UnOp:
+			if ref.Op != token.MUL {
+				return false
+			}
+		case *ssa.FieldAddr:
+			if !readOnly(ref) {
+				return false
+			}
+		case *ssa.Call:
+			if !isGetterCall(ref) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// closureEscapes reports whether the closure mc capturing v is used other
+// than by direct calls and starts (see launchedClosure), or lets v escape
+// from its body.
+func closureEscapes(mc *ssa.MakeClosure, v ssa.Value) bool {
+	for _, ref := range *mc.Referrers() {
+		if _, ok := ref.(*ssa.DebugRef); ok {
+			continue
+		}
+		if launched, _ := launchedClosure(ref); launched != mc {
+			return true
+		}
+	}
+	fn := mc.Fn.(*ssa.Function)
+	for i, binding := range mc.Bindings {
+		if binding == v && addrEscapes(fn.FreeVars[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldAddrEscapes(fa *ssa.FieldAddr) bool {
+	for _, ref := range *fa.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef:
+		case *ssa.UnOp:
+			if ref.Op != token.MUL {
+				return true
+			}
+		case *ssa.Store:
+			if ref.Addr != fa {
+				return true
+			}
+		case *ssa.FieldAddr:
+			if fieldAddrEscapes(ref) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func isPathPrefix(prefix, path []int) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// zeroFact returns the fact of the zero value of t; an undetermined type
+// may be nil.
+func zeroFact(t types.Type) nilFact {
+	if t == nil {
+		return nilFact{Status: NilStatusDefinitelyNil}
+	}
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface, *types.Map, *types.Slice, *types.Chan, *types.Signature:
+		return nilFact{Status: NilStatusDefinitelyNil}
+	}
+	return nilFact{Status: NilStatusNotNil}
+}
@@ -0,0 +1,178 @@
+package analyzer
+
+import (
+	"go/types"
+	"maps"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// fieldSets lists the fields of proto messages passed to fn as parameters
+// that fn assigns, directly, through local aliases of their addresses or by
+// passing the message or field address on to a callee that does, each with
+// the join of the values assigned. Message pointers that fn assigns through
+// pointer parameters are listed as well. A field is marked Always when it is
+// assigned on every path to a normal return of fn, through addresses that
+// cannot denote any other field.
+func (a *NilFlowAnalyzer) fieldSets(fn *ssa.Function) []FieldSet {
+	saved := a.visited
+	a.visited = newValueCache()
+	defer func() { a.visited = saved }()
+
+	var (
+		order []paramField
+		names = make(map[paramField]string)
+		facts = make(map[paramField]nilFact)
+		gen   = make(map[*ssa.BasicBlock][]paramField)
+	)
+	// add records an assignment of k in b; must reports whether it is
+	// certain to assign k when b runs.
+	add := func(b *ssa.BasicBlock, k paramField, name string, f nilFact, must bool) {
+		if must {
+			gen[b] = append(gen[b], k)
+		}
+		if old, ok := facts[k]; ok {
+			facts[k] = old.join(f)
+			return
+		}
+		order = append(order, k)
+		names[k] = name
+		facts[k] = f
+	}
+
+	// target records an assignment through an address fn received.
+	target := func(b *ssa.BasicBlock, addr ssa.Value, f nilFact, must bool) {
+		switch addr := addr.(type) {
+		case *ssa.FieldAddr:
+			i := paramIndexOf(fn, addr.X)
+			msg := receiverNamedType(addr.X.Type())
+			if i < 0 || msg == nil || !implementsProtoMessage(msg) {
+				return
+			}
+			add(b, paramField{i, addr.Field}, msg.Underlying().(*types.Struct).Field(addr.Field).Name(), f, must)
+		case *ssa.Parameter:
+			if i := paramIndexOf(fn, addr); i >= 0 && isMessageSlot(addr.Type()) {
+				add(b, paramField{i, PointeeField}, "", f, must)
+			}
+		}
+	}
+
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *ssa.Store:
+				targets := addrTargets(instr.Addr)
+				for _, addr := range targets {
+					target(b, addr, a.factAt(instr.Val, instr), len(targets) == 1)
+				}
+			case *ssa.Call:
+				callee := instr.Call.StaticCallee()
+				if callee == nil {
+					continue
+				}
+				args := callArgs(instr)
+				for _, set := range a.Summary(callee).Sets {
+					if set.Param >= len(args) {
+						continue
+					}
+					f := a.instantiate(set.Value.fact(), args, instr)
+					if set.Field != PointeeField {
+						if i := paramIndexOf(fn, args[set.Param]); i >= 0 {
+							add(b, paramField{i, set.Field}, set.Name, f, set.Always)
+						}
+						continue
+					}
+					targets := addrTargets(args[set.Param])
+					for _, addr := range targets {
+						target(b, addr, f, set.Always && len(targets) == 1)
+					}
+				}
+			}
+		}
+	}
+
+	always := a.assignedOnEveryPath(fn, gen)
+	var sets []FieldSet
+	for _, k := range order {
+		sets = append(sets, FieldSet{Param: k.param, Field: k.field, Name: names[k], Value: facts[k].summary(), Always: always[k]})
+	}
+	return sets
+}
+
+// paramField identifies a field of the message a parameter points to.
+type paramField struct{ param, field int }
+
+// assignedOnEveryPath returns the keys assigned on every path from the
+// entry of fn to a normal return, given the keys each block assigns.
+func (a *NilFlowAnalyzer) assignedOnEveryPath(fn *ssa.Function, gen map[*ssa.BasicBlock][]paramField) map[paramField]bool {
+	// out holds the keys assigned on every path through the end of a
+	// block; blocks missing from it have not been reached yet.
+	out := make(map[*ssa.BasicBlock]map[paramField]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, b := range fn.Blocks {
+			if a.isDead(b) {
+				continue
+			}
+			var in map[paramField]bool
+			if len(b.Preds) == 0 {
+				in = make(map[paramField]bool)
+			}
+			for _, pred := range b.Preds {
+				po, ok := out[pred]
+				if !ok || a.isDead(pred) {
+					continue
+				}
+				if in == nil {
+					in = maps.Clone(po)
+					continue
+				}
+				for k := range in {
+					if !po[k] {
+						delete(in, k)
+					}
+				}
+			}
+			if in == nil {
+				continue
+			}
+			for _, k := range gen[b] {
+				in[k] = true
+			}
+			if old, ok := out[b]; !ok || !maps.Equal(old, in) {
+				out[b] = in
+				changed = true
+			}
+		}
+	}
+
+	var always map[paramField]bool
+	for _, b := range fn.Blocks {
+		if len(b.Instrs) == 0 || a.isDead(b) {
+			continue
+		}
+		if _, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); !ok {
+			continue
+		}
+		if always == nil {
+			always = maps.Clone(out[b])
+			continue
+		}
+		for k := range always {
+			if !out[b][k] {
+				delete(always, k)
+			}
+		}
+	}
+	return always
+}
+
+// paramIndexOf returns the index of v in fn.Params, or -1.
+func paramIndexOf(fn *ssa.Function, v ssa.Value) int {
+	for i, p := range fn.Params {
+		if p == v {
+			return i
+		}
+	}
+	return -1
+}
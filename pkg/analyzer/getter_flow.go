@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Generated proto getters are modeled as field loads with a nil-safe
+// receiver:
+//
+//	func (x *User) GetProfile() *Profile {
+//		if x != nil {
+//			return x.Profile
+//		}
+//		return nil
+//	}
+//
+// so x.GetProfile() is nil when x is nil or its Profile field is unset.
+
+// getterFact computes the fact of a call of a generated proto getter.
+func (a *NilFlowAnalyzer) getterFact(call *ssa.Call) (nilFact, bool) {
+	field, ok := getterFieldIndex(call)
+	if !ok {
+		return nilFact{}, false
+	}
+	return a.getterLoadFact(call.Call.Args[0], field, call), true
+}
+
+// getterLoadFact computes the fact of field number field read through a
+// getter from the message recv points to, at the program point at. When
+// recv is itself read by a getter from a tracked local message, every value
+// stored into that field is followed, so chains like a.GetB().GetC() are as
+// precise as the stores into a and its sub-messages.
+func (a *NilFlowAnalyzer) getterLoadFact(recv ssa.Value, field int, at ssa.Instruction) nilFact {
+	if isNilConst(recv) {
+		return nilFact{Status: NilStatusDefinitelyNil}
+	}
+	if inner, ok := recv.(*ssa.Call); ok && !a.tracing[inner] {
+		if innerField, ok := getterFieldIndex(inner); ok {
+			a.tracing[inner] = true
+			f, ok := a.chainedGetterFact(inner.Call.Args[0], innerField, inner, field, at)
+			delete(a.tracing, inner)
+			if ok {
+				return f
+			}
+		}
+	}
+	f := a.fieldLoadFact(recv, []int{field}, at)
+	// A nil receiver yields nil; a non-nil one only the field.
+	r := a.factAt(recv, at)
+	if r.Status == NilStatusNotNil {
+		r.Status = nilStatusBottom
+	}
+	return f.join(r)
+}
+
+// chainedGetterFact computes the fact of field number field of the message
+// held in field number innerField of the message recv points to, as read by
+// the getter call inner. It fails unless recv is a tracked local allocation
+// whose field is defined only by its zero value and direct stores.
+func (a *NilFlowAnalyzer) chainedGetterFact(recv ssa.Value, innerField int, inner *ssa.Call, field int, at ssa.Instruction) (nilFact, bool) {
+	alloc, ok := recv.(*ssa.Alloc)
+	if !ok || addrEscapes(alloc) {
+		return nilFact{}, false
+	}
+	defs, checked, _ := reachingFieldDefs(alloc, []int{innerField}, inner)
+	if len(defs) == 0 || len(checked) > 0 {
+		return nilFact{}, false
+	}
+	f := nilFact{Status: nilStatusBottom}
+	for _, def := range defs {
+		switch def := def.(type) {
+		case *ssa.Alloc:
+			// The inner message is unset, so the getter's receiver is nil.
+			f = f.join(nilFact{Status: NilStatusDefinitelyNil})
+		case *ssa.Store:
+			if _, prefix := fieldAddrPath(def.Addr); len(prefix) != 1 {
+				return nilFact{}, false
+			}
+			f = f.join(a.getterLoadFact(def.Val, field, at))
+		default:
+			return nilFact{}, false
+		}
+	}
+	return f, true
+}
+
+// getterFieldIndex returns the index of the field read by call if it
+// invokes a generated proto getter.
+func getterFieldIndex(call *ssa.Call) (int, bool) {
+	msg, field := getterField(call)
+	if field == nil || len(call.Call.Args) != 1 {
+		return 0, false
+	}
+	st := msg.Underlying().(*types.Struct)
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i) == field {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// getterField returns the message type and field read by call if it
+// invokes a generated proto getter.
+func getterField(call *ssa.Call) (*types.Named, *types.Var) {
+	fn := call.Call.StaticCallee()
+	if fn == nil {
+		return nil, nil
+	}
+	method, _ := fn.Object().(*types.Func)
+	return protoGetterField(method)
+}
+
+// isGetterCall reports whether call invokes a generated proto getter.
+func isGetterCall(call *ssa.Call) bool {
+	_, field := getterField(call)
+	return field != nil
+}
+
+// unsetFieldReason explains a nil v read at instr from a field of a proto
+// message, directly or through its getter, e.g. "User.Profile may be unset".
+// A getter called on a message that is nil is blamed on that message
+// instead: "User is nil", or "Account.User may be unset" when it was read
+// from an unset field itself.
+func (a *NilFlowAnalyzer) unsetFieldReason(v ssa.Value, instr ssa.Instruction) string {
+	var (
+		msg   *types.Named
+		field *types.Var
+	)
+	switch v := v.(type) {
+	case *ssa.Call:
+		msg, field = getterField(v)
+		if field == nil {
+			return ""
+		}
+		recv := v.Call.Args[0]
+		if isNilConst(recv) || a.factAt(recv, instr).resolve() == NilStatusDefinitelyNil {
+			if reason := a.unsetFieldReason(recv, instr); reason != "" {
+				return reason
+			}
+			return msg.Obj().Name() + " is nil"
+		}
+	case *ssa.UnOp:
+		fa, ok := v.X.(*ssa.FieldAddr)
+		if !ok || v.Op != token.MUL {
+			return ""
+		}
+		named := receiverNamedType(fa.X.Type())
+		if named == nil || !implementsProtoMessage(named) {
+			return ""
+		}
+		msg, field = named, fieldVar(named, fa.Field)
+	}
+	if field == nil {
+		return ""
+	}
+	return msg.Obj().Name() + "." + field.Name() + " may be unset"
+}
@@ -1,16 +1,44 @@
 package analyzer
 
 import (
+	"go/token"
 	"go/types"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ssa"
 )
 
-// analyzeHandler performs direct-field SSA analysis for a single gRPC handler.
-// It looks for assignments to risky response fields and reports if the assigned
-// value may be nil according to NilFlowAnalyzer.
-func analyzeHandler(pass *analysis.Pass, protoAnalyzer *ProtoFieldAnalyzer, nilAnalyzer *NilFlowAnalyzer, h HandlerInfo) {
+// handlerChecker carries the state shared by the checks of a single handler.
+type handlerChecker struct {
+	pass          *analysis.Pass
+	protoAnalyzer *ProtoFieldAnalyzer
+	nilAnalyzer   *NilFlowAnalyzer
+	h             HandlerInfo
+	maxDepth      int
+
+	// visited holds the sub-message allocations already validated, so that
+	// recursive message graphs (u.Manager = u) terminate.
+	visited map[*ssa.Alloc]bool
+	// unassigned holds the implicit nils already reported, so that response
+	// instances merging at one return are reported once.
+	unassigned map[reportKey]bool
+	// stored holds the nil values already reported at stores and calls, so
+	// that a store matched by several instances is reported once.
+	stored map[reportKey]bool
+}
+
+type reportKey struct {
+	pos  token.Pos
+	path string
+}
+
+// analyzeHandler performs SSA analysis for a single gRPC handler. It looks
+// for assignments to risky fields of the response instances the handler
+// returns and reports if the assigned value may be nil according to
+// NilFlowAnalyzer. Sub-messages allocated in the
+// handler and stored into the response are validated recursively, up to
+// maxDepth levels below the response.
+func analyzeHandler(pass *analysis.Pass, protoAnalyzer *ProtoFieldAnalyzer, nilAnalyzer *NilFlowAnalyzer, h HandlerInfo, maxDepth int) {
 	if h.Function == nil {
 		return
 	}
@@ -26,125 +54,645 @@ func analyzeHandler(pass *analysis.Pass, protoAnalyzer *ProtoFieldAnalyzer, nilA
 	}
 
 	msgInfo := protoAnalyzer.AnalyzeMessage(respNamed)
-	if msgInfo == nil || len(msgInfo.Risky) == 0 {
-		// No risky fields => nothing to check.
+	if msgInfo == nil || len(msgInfo.Fields) == 0 {
+		// No fields => nothing to check.
 		return
 	}
 
-	// Track which risky fields are explicitly assigned anywhere in this handler.
-	assigned := make(map[string]bool)
+	c := &handlerChecker{
+		pass:          pass,
+		protoAnalyzer: protoAnalyzer,
+		nilAnalyzer:   nilAnalyzer,
+		h:             h,
+		maxDepth:      maxDepth,
+		visited:       make(map[*ssa.Alloc]bool),
+		unassigned:    make(map[reportKey]bool),
+		stored:        make(map[reportKey]bool),
+	}
+	respPath := respNamed.Obj().Name()
+
+	// Elements stored into a slice before it is assigned to the response,
+	// e.g. users[i] = u; resp.Users = users, are matched by the slice type;
+	// elements of slices loaded from a message field are checked with the
+	// message instance owning it.
+	for _, fn := range handlerFuncs(h.Function) {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				store, ok := instr.(*ssa.Store)
+				if !ok {
+					continue
+				}
+				ia, ok := store.Addr.(*ssa.IndexAddr)
+				if !ok || isFieldLoad(ia.X) {
+					continue
+				}
+				if fieldInfo, ok := matchRepeatedSliceField(ia.X.Type(), msgInfo); ok {
+					c.checkElementStore(store, ia, fieldInfo, respPath, "", 0)
+				}
+			}
+		}
+	}
+
+	// Check each response instance reaching a return on its own: stores
+	// into a scratch response built for logging do not count for the one
+	// returned, and risky fields a returned instance never assigns are
+	// reported at the returns it reaches. The fields of a response the
+	// handler did not allocate may have been set anywhere.
+	for _, r := range c.responseInstances(respNamed) {
+		assigned := c.checkInstance(r.inst, msgInfo, respPath, "", 0)
+		if r.inst.alloc == nil {
+			continue
+		}
+		for _, ret := range r.rets {
+			c.reportUnassigned(ret, msgInfo, respPath, assigned)
+		}
+	}
+}
 
-	// NOTE: For now we conservatively treat any store whose base address has
-	// type *Resp as a response field assignment, without restricting to
-	// specific alloc sites. This is sufficient for unit tests and keeps the
-	// analysis simple; it can be refined later to track specific response
-	// instances.
-
-	// For each instruction, look for stores to response fields or slice elements.
-	for _, b := range h.Function.Blocks {
-		for _, instr := range b.Instrs {
-			store, ok := instr.(*ssa.Store)
-			if !ok {
+// responseInstance is a response message instance and the returns of the
+// handler it reaches.
+type responseInstance struct {
+	inst *msgInstance
+	rets []*ssa.Return
+}
+
+// responseInstances returns the response instances of the handler: one per
+// allocation reaching the first result of a return, through Phis and local
+// variables such as named results, and one matched by type for responses
+// of any other origin, such as the result of a builder function. The latter
+// does not cover responses allocated by the handler.
+func (c *handlerChecker) responseInstances(respNamed *types.Named) []*responseInstance {
+	var (
+		out     []*responseInstance
+		byAlloc = make(map[*ssa.Alloc]*responseInstance)
+		typed   *responseInstance
+	)
+	for _, b := range c.h.Function.Blocks {
+		if len(b.Instrs) == 0 || c.nilAnalyzer.isDead(b) {
+			continue
+		}
+		ret, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return)
+		if !ok || len(ret.Results) == 0 || !isResponsePointer(ret.Results[0].Type(), respNamed) {
+			continue
+		}
+		for _, v := range addrTargets(ret.Results[0]) {
+			alloc, _ := v.(*ssa.Alloc)
+			var r *responseInstance
+			switch {
+			case isNilConst(v):
 				continue
+			case alloc != nil:
+				r = byAlloc[alloc]
+				if r == nil {
+					r = &responseInstance{inst: &msgInstance{named: respNamed, alloc: alloc}}
+					byAlloc[alloc] = r
+					out = append(out, r)
+				}
+			default:
+				if typed == nil {
+					typed = &responseInstance{inst: &msgInstance{named: respNamed}}
+					out = append(out, typed)
+				}
+				r = typed
+			}
+			if len(r.rets) == 0 || r.rets[len(r.rets)-1] != ret {
+				r.rets = append(r.rets, ret)
 			}
+		}
+	}
+	return out
+}
 
-			switch addr := store.Addr.(type) {
-			case *ssa.FieldAddr:
-				// Direct struct field assignment, e.g. resp.Profile = v.
-				if !isResponsePointer(addr.X.Type(), respNamed) {
-					continue
+// isFieldLoad reports whether v is loaded from a struct field.
+func isFieldLoad(v ssa.Value) bool {
+	load, ok := v.(*ssa.UnOp)
+	if !ok || load.Op != token.MUL {
+		return false
+	}
+	_, ok = load.X.(*ssa.FieldAddr)
+	return ok
+}
+
+// checkFieldStore validates a store through fa of the message-typed field fi at
+// root.ownerPath (e.g. "GetUserResponse" + "User" for fi = Profile) and descends into the stored
+// sub-message or slice literal. owner is the message instance the field
+// belongs to and depth its nesting level below the response.
+func (c *handlerChecker) checkFieldStore(store *ssa.Store, fa *ssa.FieldAddr, fi FieldInfo, owner *msgInstance, root, ownerPath string, depth int) {
+	relPath := joinFieldPath(ownerPath, fi.Name)
+	path := root + "." + relPath
+
+	// Only scalar message-pointer fields are treated as direct-field risks.
+	if isDirectFieldRisk(fi) && !c.fixedByDefer(owner, fa.Field, store) {
+		// Check the value being stored for potential nil.
+		if c.mayBeNil(store.Val, store) && c.firstStored(store.Pos(), path) {
+			c.pass.Reportf(
+				store.Pos(),
+				"potential nil field in gRPC response %s (handler %s.%s)%s%s",
+				path,
+				c.h.ServiceName,
+				c.h.MethodName,
+				c.reasonNote(store),
+				consumerNote(c.pass, fi),
+			)
+		}
+	}
+
+	if alloc := messageAlloc(store.Val); alloc != nil {
+		c.checkSubMessage(alloc, store, owner, fa.Field, root, relPath, depth+1)
+	}
+	switch val := store.Val.(type) {
+	case *ssa.Slice:
+		// Composite literals of repeated fields, e.g. Users: []*User{...},
+		// are built in a backing array that is sliced and stored.
+		if arr, ok := val.X.(*ssa.Alloc); ok && fi.Risk == FieldRiskRepeatedMessagePointer {
+			for _, ref := range *arr.Referrers() {
+				if ia, ok := ref.(*ssa.IndexAddr); ok {
+					c.checkElementStores(ia, fi, root, ownerPath, depth)
 				}
+			}
+		}
+	}
+}
 
-				// Map field index to FieldInfo.
-				fieldInfo, ok := msgInfo.FieldByID[addr.Field]
+// checkCallSets handles the fields of inst that call assigns according to
+// the summaries of its callees like stores in the handler: they are
+// reported at the call if the value may be nil, and count as assigned if
+// every callee assigns them on every path. relPath names inst relative to
+// root.
+func (c *handlerChecker) checkCallSets(call *ssa.Call, inst *msgInstance, msgInfo *ProtoMessageInfo, root, relPath string, assigned map[string]bool) {
+	args := callArgs(call)
+	callees := c.nilAnalyzer.callees(call)
+	always := make(map[string]int)
+	for _, callee := range callees {
+		sets := make(map[string]bool)
+		for _, set := range c.nilAnalyzer.Summary(callee).Sets {
+			if set.Param >= len(args) {
+				continue
+			}
+			fields, must := setFields(set, args[set.Param], inst)
+			for _, field := range fields {
+				fi, ok := msgInfo.FieldByID[field]
 				if !ok {
 					continue
 				}
-				// Only scalar message-pointer fields are treated as direct-field risks.
-				if fieldInfo.Risk != FieldRiskMessagePointer {
+				if must && !sets[fi.Name] {
+					sets[fi.Name] = true
+					always[fi.Name]++
+				}
+				if !isDirectFieldRisk(fi) || c.fixedByDefer(inst, field, call) {
 					continue
 				}
+				c.nilAnalyzer.Reset()
+				f := c.nilAnalyzer.instantiate(set.Value.fact(), args, call)
+				status := c.nilAnalyzer.liftTo(f, call.Parent(), c.h.Function).resolve()
+				path := root + "." + joinFieldPath(relPath, fi.Name)
+				if (status == NilStatusMaybeNil || status == NilStatusDefinitelyNil || status == NilStatusUnknown) && c.firstStored(call.Pos(), path) {
+					c.pass.Reportf(
+						call.Pos(),
+						"potential nil field in gRPC response %s (handler %s.%s); set by %s%s",
+						path,
+						c.h.ServiceName,
+						c.h.MethodName,
+						callee.Name(),
+						consumerNote(c.pass, fi),
+					)
+				}
+			}
+		}
+	}
+	for name, n := range always {
+		if n == len(callees) {
+			assigned[name] = true
+		}
+	}
+}
 
-				// Mark this risky field as explicitly assigned in the handler,
-				// regardless of whether the assigned value is nil or not.
-				assigned[fieldInfo.Name] = true
+// setFields returns the fields of inst that set assigns when its parameter
+// is bound to arg: set's field if arg denotes inst, or the fields of inst
+// whose addresses arg may hold if set assigns through the parameter. must
+// reports whether the call assigns them for certain: set is Always, and
+// arg holds no other address.
+func setFields(set FieldSet, arg ssa.Value, inst *msgInstance) (fields []int, must bool) {
+	if set.Field != PointeeField {
+		if inst.refersTo(arg) {
+			return []int{set.Field}, set.Always
+		}
+		return nil, false
+	}
+	for _, fa := range storedFields(arg) {
+		if inst.refersTo(fa.X) {
+			fields = append(fields, fa.Field)
+		}
+	}
+	return fields, set.Always && len(addrTargets(arg)) == 1
+}
 
-				// Check the value being stored for potential nil.
-				nilAnalyzer.Reset()
-				if !nilAnalyzer.IsMaybeNil(store.Val) {
-					continue
-				}
+// checkElementStores validates every store through the element address ia
+// of the repeated field fi owned by the message at root.relPath.
+func (c *handlerChecker) checkElementStores(ia *ssa.IndexAddr, fi FieldInfo, root, relPath string, depth int) {
+	for _, ref := range *ia.Referrers() {
+		if store, ok := ref.(*ssa.Store); ok && store.Addr == ia {
+			c.checkElementStore(store, ia, fi, root, relPath, depth)
+		}
+	}
+}
 
-				// Report diagnostic for direct field.
-				pass.Reportf(
-					store.Pos(),
-					"potential nil field in gRPC response %s.%s (handler %s.%s)",
-					respNamed.Obj().Name(),
-					fieldInfo.Name,
-					h.ServiceName,
-					h.MethodName,
-				)
-
-			case *ssa.IndexAddr:
-				// Slice/array element assignment, e.g. resp.Users[i] = v.
-				// We conservatively match based on the element container type:
-				// if the slice type matches a repeated message field on the response,
-				// we treat this as a potential nil element assignment.
-				fieldInfo, ok := matchRepeatedSliceField(addr.X.Type(), msgInfo)
-				if !ok || fieldInfo.Risk != FieldRiskRepeatedMessagePointer {
-					continue
-				}
+// checkElementStore validates a store into an element of the repeated field
+// fi, e.g. resp.Users[i] = v, and descends into the stored sub-message.
+// relPath names the message owning fi relative to root.
+func (c *handlerChecker) checkElementStore(store *ssa.Store, ia *ssa.IndexAddr, fi FieldInfo, root, relPath string, depth int) {
+	if fi.Risk != FieldRiskRepeatedMessagePointer {
+		return
+	}
+	relPath = joinFieldPath(relPath, fi.Name)
+
+	// Check the value being stored for potential nil.
+	if c.mayBeNil(store.Val, store) && c.firstStored(store.Pos(), root+"."+relPath+"[]") {
+		// Report diagnostic for slice element.
+		c.pass.Reportf(
+			store.Pos(),
+			"potential nil element in gRPC response slice %s (handler %s.%s)%s",
+			root+"."+relPath,
+			c.h.ServiceName,
+			c.h.MethodName,
+			c.reasonNote(store),
+		)
+	}
+
+	if alloc := messageAlloc(store.Val); alloc != nil {
+		c.checkSubMessage(alloc, store, nil, 0, root, relPath+elementSuffix(ia), depth+1)
+	}
+}
+
+// fixedByDefer reports whether a closure deferred on every path through the
+// handler leaves field of inst non-nil whenever the response is returned,
+// e.g. defer func() { if resp.Meta == nil { resp.Meta = defaultMeta() } }(),
+// so that the value stored at instr is patched. Deferred calls run in
+// reverse order, so the fix-up must not be followed by another deferred
+// closure writing the field, including the one instr belongs to.
+func (c *handlerChecker) fixedByDefer(inst *msgInstance, field int, instr ssa.Instruction) bool {
+	if inst == nil {
+		return false
+	}
+	// Walk the defers from the one running last: the first closure writing
+	// the field decides, unless it is a fix-up that may not be deferred.
+	for _, d := range deferredCalls(c.h.Function) {
+		fn := closureCallee(d.Common())
+		if fn == nil {
+			continue
+		}
+		if encloses(fn, instr.Parent()) {
+			return false
+		}
+		if !c.writesField(fn, inst, field) {
+			continue
+		}
+		if !c.setsOnExit(fn, inst, field) {
+			return false
+		}
+		if runsOnExit(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// encloses reports whether inner is outer or a closure nested in it.
+func encloses(outer, inner *ssa.Function) bool {
+	for ; inner != nil; inner = inner.Parent() {
+		if inner == outer {
+			return true
+		}
+	}
+	return false
+}
 
-				// Check the value being stored for potential nil.
-				nilAnalyzer.Reset()
-				if !nilAnalyzer.IsMaybeNil(store.Val) {
+// writesField reports whether fn or a closure nested in it stores into
+// field of inst.
+func (c *handlerChecker) writesField(fn *ssa.Function, inst *msgInstance, field int) bool {
+	for _, f := range handlerFuncs(fn) {
+		for _, b := range f.Blocks {
+			for _, instr := range b.Instrs {
+				store, ok := instr.(*ssa.Store)
+				if !ok {
 					continue
 				}
+				for _, fa := range storedFields(store.Addr) {
+					if fa.Field == field && inst.refersTo(fa.X) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
 
-				// Report diagnostic for slice element.
-				pass.Reportf(
-					store.Pos(),
-					"potential nil element in gRPC response slice %s (handler %s.%s)",
-					fieldInfo.Name,
-					h.ServiceName,
-					h.MethodName,
-				)
+// setsOnExit reports whether every return of fn is reached with field of
+// inst holding a non-nil value: stored by fn, checked against nil, or
+// irrelevant because inst itself was found to be nil.
+func (c *handlerChecker) setsOnExit(fn *ssa.Function, inst *msgInstance, field int) bool {
+	isField := func(addr ssa.Value) bool {
+		fa, ok := addr.(*ssa.FieldAddr)
+		return ok && fa.Field == field && inst.refersTo(fa.X)
+	}
+	// edgeSet reports whether the edge from pred to succ shows the field
+	// to be non-nil or inst to be nil.
+	edgeSet := func(pred, succ *ssa.BasicBlock) bool {
+		ifInstr := branchIf(pred)
+		if ifInstr == nil {
+			return false
+		}
+		x, _, ok := nilComparison(ifInstr.Cond)
+		if !ok {
+			return false
+		}
+		s, _ := branchStatus(x, pred, succ)
+		if load, ok := x.(*ssa.UnOp); ok && load.Op == token.MUL && isField(load.X) {
+			return s == NilStatusNotNil
+		}
+		return s == NilStatusDefinitelyNil && inst.refersTo(x)
+	}
+
+	// Iterate to the greatest fixpoint of "set at the end of the block".
+	set := make(map[*ssa.BasicBlock]bool)
+	for _, b := range fn.Blocks {
+		set[b] = len(b.Preds) > 0
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range fn.Blocks {
+			in := len(b.Preds) > 0
+			for _, pred := range b.Preds {
+				if !c.nilAnalyzer.isDead(pred) && !set[pred] && !edgeSet(pred, b) {
+					in = false
+				}
 			}
+			out := in
+			for _, instr := range b.Instrs {
+				if store, ok := instr.(*ssa.Store); ok && isField(store.Addr) {
+					out = !c.mayBeNil(store.Val, store)
+				}
+			}
+			if out != set[b] {
+				set[b], changed = out, true
+			}
+		}
+	}
+	for _, b := range fn.Blocks {
+		if len(b.Instrs) == 0 || c.nilAnalyzer.isDead(b) {
+			continue
 		}
+		if _, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok && !set[b] {
+			return false
+		}
+	}
+	return true
+}
+
+// mayBeNil reports whether v may be nil at instr, which belongs to the
+// handler or to one of its closures.
+func (c *handlerChecker) mayBeNil(v ssa.Value, instr ssa.Instruction) bool {
+	c.nilAnalyzer.Reset()
+	s := c.nilAnalyzer.liftedFactAt(v, instr, c.h.Function).resolve()
+	return s == NilStatusMaybeNil || s == NilStatusDefinitelyNil || s == NilStatusUnknown
+}
+
+// messageAlloc returns the allocation v denotes: v itself, or the only
+// value ever stored into the variable v is loaded from, e.g. a sub-message
+// captured by a goroutine.
+func messageAlloc(v ssa.Value) *ssa.Alloc {
+	if alloc, ok := v.(*ssa.Alloc); ok {
+		return alloc
 	}
-	// After scanning all stores, report implicit nils for risky fields that
-	// were never assigned anywhere in the handler.
-	for _, b := range h.Function.Blocks {
-		for _, instr := range b.Instrs {
-			ret, ok := instr.(*ssa.Return)
-			if !ok || len(ret.Results) == 0 {
+	load, ok := v.(*ssa.UnOp)
+	if !ok || load.Op != token.MUL {
+		return nil
+	}
+	vals, ok := variableStores(load.X)
+	if !ok || len(vals) != 1 {
+		return nil
+	}
+	alloc, _ := vals[0].(*ssa.Alloc)
+	return alloc
+}
+
+// handlerFuncs returns fn and the closures nested in it, such as the bodies
+// of goroutines and errgroup tasks, which may fill in the response.
+func handlerFuncs(fn *ssa.Function) []*ssa.Function {
+	fns := []*ssa.Function{fn}
+	for i := 0; i < len(fns); i++ {
+		fns = append(fns, fns[i].AnonFuncs...)
+	}
+	return fns
+}
+
+// msgInstance identifies a message value built by the handler: either the
+// response itself (alloc == nil, matched by type) or a sub-message allocation
+// stored into field of its parent instance.
+type msgInstance struct {
+	named  *types.Named
+	alloc  *ssa.Alloc
+	parent *msgInstance
+	field  int
+}
+
+// refersTo reports whether v denotes inst: the allocation itself, a load of
+// a variable only ever holding it, or a reload of the parent field it was
+// stored into (resp.User.Profile = p).
+func (inst *msgInstance) refersTo(v ssa.Value) bool {
+	if inst.alloc == nil {
+		return isResponsePointer(v.Type(), inst.named) && !isLocalAlloc(v)
+	}
+	if v == inst.alloc {
+		return true
+	}
+	load, ok := v.(*ssa.UnOp)
+	if !ok || load.Op != token.MUL {
+		return false
+	}
+	if vals, ok := variableStores(load.X); ok && len(vals) > 0 {
+		// A variable holding inst, e.g. one captured by a goroutine or a
+		// named result. Fields cannot be stored through it while it is nil.
+		for _, val := range vals {
+			if l, ok := val.(*ssa.UnOp); ok && variableCell(l.X) == variableCell(load.X) {
+				// x = x
 				continue
 			}
-			// First result is the response value in a unary handler.
-			resVal := ret.Results[0]
-			if !isResponsePointer(resVal.Type(), respNamed) {
+			if isNilConst(val) {
 				continue
 			}
+			if !inst.refersTo(val) {
+				return false
+			}
+		}
+		return true
+	}
+	if inst.parent == nil {
+		return false
+	}
+	fa, ok := load.X.(*ssa.FieldAddr)
+	return ok && fa.Field == inst.field && inst.parent.refersTo(fa.X)
+}
 
-			for _, fi := range msgInfo.Risky {
-				if fi.Risk != FieldRiskMessagePointer {
-					continue
-				}
-				if assigned[fi.Name] {
-					continue
-				}
+// isLocalAlloc reports whether v only ever holds allocations of the
+// handler, such as a scratch response built for logging.
+func isLocalAlloc(v ssa.Value) bool {
+	targets := addrTargets(v)
+	for _, t := range targets {
+		if _, ok := t.(*ssa.Alloc); !ok && !isNilConst(t) {
+			return false
+		}
+	}
+	return len(targets) > 0
+}
 
-				pass.Reportf(
-					ret.Pos(),
-					"implicit nil field in gRPC response %s.%s (handler %s.%s)",
-					respNamed.Obj().Name(),
-					fi.Name,
-					h.ServiceName,
-					h.MethodName,
-				)
+// checkSubMessage validates the sub-message allocated by alloc, which parent
+// stores into the response at root.relPath: into field of the instance
+// owner, or into a slice element when owner is nil. Fields assigned through
+// the instance are checked like top-level response fields; risky fields that
+// are never assigned are reported as implicit nils at the parent store.
+func (c *handlerChecker) checkSubMessage(alloc *ssa.Alloc, parent *ssa.Store, owner *msgInstance, field int, root, relPath string, depth int) {
+	if depth > c.maxDepth || c.visited[alloc] {
+		return
+	}
+	named := receiverNamedType(alloc.Type())
+	if named == nil || !implementsProtoMessage(named) {
+		return
+	}
+	c.visited[alloc] = true
+
+	inst := &msgInstance{named: named, alloc: alloc, parent: owner, field: field}
+
+	msgInfo := c.protoAnalyzer.AnalyzeMessage(named)
+	assigned := c.checkInstance(inst, msgInfo, root, relPath, depth)
+	c.reportUnassigned(parent, msgInfo, root+"."+relPath, assigned)
+}
+
+// checkInstance checks the stores and calls of the handler and its closures
+// that assign fields of inst, which is named root.relPath and nested depth
+// levels below the response, and returns the names of the fields assigned,
+// whether the assigned values may be nil or not.
+func (c *handlerChecker) checkInstance(inst *msgInstance, msgInfo *ProtoMessageInfo, root, relPath string, depth int) map[string]bool {
+	assigned := make(map[string]bool)
+	for _, fn := range handlerFuncs(c.h.Function) {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Call:
+					c.checkCallSets(instr, inst, msgInfo, root, relPath, assigned)
+				case *ssa.Store:
+					// u.Profile = v, directly or through an alias of the
+					// field's address. An alias that may also hold another
+					// address assigns neither field for certain.
+					must := len(addrTargets(instr.Addr)) == 1
+					for _, fa := range storedFields(instr.Addr) {
+						if !inst.refersTo(fa.X) {
+							continue
+						}
+						if fieldInfo, ok := msgInfo.FieldByID[fa.Field]; ok {
+							if must {
+								assigned[fieldInfo.Name] = true
+							}
+							c.checkFieldStore(instr, fa, fieldInfo, inst, root, relPath, depth)
+						}
+					}
+				case *ssa.FieldAddr:
+					// u.Friends[i] = v, through a load of the slice field.
+					fieldInfo, ok := msgInfo.FieldByID[instr.Field]
+					if !ok || !inst.refersTo(instr.X) {
+						continue
+					}
+					for _, ref := range *instr.Referrers() {
+						load, ok := ref.(*ssa.UnOp)
+						if !ok {
+							continue
+						}
+						for _, loadRef := range *load.Referrers() {
+							if ia, ok := loadRef.(*ssa.IndexAddr); ok && ia.X == load {
+								c.checkElementStores(ia, fieldInfo, root, relPath, depth)
+							}
+						}
+					}
+				}
 			}
 		}
 	}
+
+	return assigned
+}
+
+// reportUnassigned reports an implicit nil at instr for every risky field of
+// msgInfo that is missing from assigned. path names the message instance.
+func (c *handlerChecker) reportUnassigned(instr ssa.Instruction, msgInfo *ProtoMessageInfo, path string, assigned map[string]bool) {
+	for _, fi := range msgInfo.Risky {
+		if !isDirectFieldRisk(fi) {
+			continue
+		}
+		key := reportKey{instr.Pos(), path + "." + fi.Name}
+		if assigned[fi.Name] || c.unassigned[key] {
+			continue
+		}
+		c.unassigned[key] = true
+
+		c.pass.Reportf(
+			instr.Pos(),
+			"implicit nil field in gRPC response %s.%s (handler %s.%s)%s",
+			path,
+			fi.Name,
+			c.h.ServiceName,
+			c.h.MethodName,
+			consumerNote(c.pass, fi),
+		)
+	}
+}
+
+// firstStored records a nil value stored into path at pos and reports
+// whether it is the first one.
+func (c *handlerChecker) firstStored(pos token.Pos, path string) bool {
+	key := reportKey{pos, path}
+	if c.stored[key] {
+		return false
+	}
+	c.stored[key] = true
+	return true
+}
+
+// joinFieldPath appends field to a dotted path relative to the response.
+func joinFieldPath(relPath, field string) string {
+	if relPath == "" {
+		return field
+	}
+	return relPath + "." + field
+}
+
+// elementSuffix renders the index of an element address, e.g. "[0]" for a
+// constant index and "[]" otherwise.
+func elementSuffix(ia *ssa.IndexAddr) string {
+	if k, ok := ia.Index.(*ssa.Const); ok && k.Value != nil {
+		return "[" + k.Value.String() + "]"
+	}
+	return "[]"
+}
+
+// isDirectFieldRisk reports whether fi is a singular sub-message field that
+// must not be nil, either by schema or because a consumer dereferences it.
+func isDirectFieldRisk(fi FieldInfo) bool {
+	return fi.Risk == FieldRiskMessagePointer || fi.Risk == FieldRiskImplicitRequirement
+}
+
+// reasonNote explains why the value stored by store may be nil, if the nil
+// flow analyzer knows more than its status.
+func (c *handlerChecker) reasonNote(store *ssa.Store) string {
+	return reasonSuffix(c.nilAnalyzer, store.Val, store)
+}
+
+// consumerNote cites the consumer site that dereferences fi, if one is known.
+func consumerNote(pass *analysis.Pass, fi FieldInfo) string {
+	if fi.ConsumerSite == "" {
+		return ""
+	}
+	return "; dereferenced without nil check at " + fi.ConsumerSite
 }
 
 // isResponsePointer reports whether t is *respNamed.
@@ -3,6 +3,7 @@ package analyzer
 import (
 	"go/token"
 	"go/types"
+	"strconv"
 
 	"golang.org/x/tools/go/ssa"
 )
@@ -17,6 +18,26 @@ const (
 	NilStatusDefinitelyNil
 )
 
+// nilStatusBottom is the identity of joinNilStatus: no evidence has been seen
+// yet, e.g. for a summary that only forwards its parameters.
+const nilStatusBottom NilStatus = -1
+
+func (s NilStatus) String() string {
+	switch s {
+	case NilStatusUnknown:
+		return "Unknown"
+	case NilStatusNotNil:
+		return "NotNil"
+	case NilStatusMaybeNil:
+		return "MaybeNil"
+	case NilStatusDefinitelyNil:
+		return "DefinitelyNil"
+	case nilStatusBottom:
+		return "Bottom"
+	}
+	return "NilStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
 // FieldRisk identifies how "risky" it is for a field to be nil in a response.
 type FieldRisk int
 
@@ -27,6 +48,20 @@ const (
 	FieldRiskImplicitRequirement
 )
 
+func (r FieldRisk) String() string {
+	switch r {
+	case FieldRiskSafe:
+		return "safe"
+	case FieldRiskMessagePointer:
+		return "message pointer"
+	case FieldRiskRepeatedMessagePointer:
+		return "repeated message pointer"
+	case FieldRiskImplicitRequirement:
+		return "implicit requirement"
+	}
+	return "FieldRisk(" + strconv.Itoa(int(r)) + ")"
+}
+
 // FieldInfo captures proto field metadata derived from generated Go structs.
 type FieldInfo struct {
 	Name            string
@@ -41,16 +76,30 @@ type FieldInfo struct {
 	IsProtoMessage  bool
 	MessageTypeName string
 	Risk            FieldRisk
+	RiskReason      string // human-readable explanation of Risk
+	ConsumerSite    string // consumer site that dereferences the field without a nil check, e.g. "client.go:12"
 }
 
 // ProtoMessageInfo represents analysis results for a proto-generated message type.
 type ProtoMessageInfo struct {
 	Type      *types.Named
+	FullName  string // proto full name, known only when a descriptor set is loaded
 	Fields    []FieldInfo
 	Risky     []FieldInfo
 	FieldByID map[int]FieldInfo
 }
 
+// PolicyMismatch records a field whose struct-tag classification disagrees
+// with the classification derived from its proto descriptor.
+type PolicyMismatch struct {
+	Field          *types.Var
+	Message        *types.Named
+	FieldName      string
+	ProtoFullName  string
+	HeuristicRisk  FieldRisk
+	DescriptorRisk FieldRisk
+}
+
 // HandlerInfo tracks gRPC handler metadata discovered in the SSA program.
 type HandlerInfo struct {
 	Function     *ssa.Function
@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+var (
+	// modelNotNil: the first result is never nil.
+	modelNotNil = &FuncSummary{Result: NilStatusNotNil}
+	// modelMaybeNil: the first result may be nil.
+	modelMaybeNil = &FuncSummary{Result: NilStatusMaybeNil}
+	// modelNilOnError: the first result is nil exactly when the error is not.
+	modelNilOnError = &FuncSummary{
+		Result:    NilStatusMaybeNil,
+		OnSuccess: &FuncSummary{Result: NilStatusNotNil},
+	}
+	// modelFirstArg: the result is nil exactly when the first argument is.
+	modelFirstArg = &FuncSummary{Result: nilStatusBottom, DependsOn: []int{0}}
+)
+
+// models lists functions whose summaries are built in rather than derived
+// from their bodies, keyed by types.Func.FullName. They cover the standard
+// error constructors and the constructors of the protobuf runtime and its
+// well-known types, which are usually not available as SSA bodies.
+var models = map[string]*FuncSummary{
+	"errors.New": modelNotNil,
+	"fmt.Errorf": modelNotNil,
+	// status.Error and status.Errorf return nil for codes.OK; see
+	// codeModelFact for calls with a constant code.
+	"google.golang.org/grpc/status.Error":  modelMaybeNil,
+	"google.golang.org/grpc/status.Errorf": modelMaybeNil,
+	"google.golang.org/grpc/status.New":    modelNotNil,
+	"google.golang.org/grpc/status.Newf":   modelNotNil,
+
+	// Must helpers panic instead of returning an error.
+	"regexp.MustCompile":      modelNotNil,
+	"regexp.MustCompilePOSIX": modelNotNil,
+	"text/template.Must":      modelNotNil,
+	"html/template.Must":      modelNotNil,
+
+	"google.golang.org/protobuf/proto.Clone":   modelFirstArg,
+	"google.golang.org/protobuf/proto.Bool":    modelNotNil,
+	"google.golang.org/protobuf/proto.Int32":   modelNotNil,
+	"google.golang.org/protobuf/proto.Int64":   modelNotNil,
+	"google.golang.org/protobuf/proto.Uint32":  modelNotNil,
+	"google.golang.org/protobuf/proto.Uint64":  modelNotNil,
+	"google.golang.org/protobuf/proto.Float32": modelNotNil,
+	"google.golang.org/protobuf/proto.Float64": modelNotNil,
+	"google.golang.org/protobuf/proto.String":  modelNotNil,
+
+	"google.golang.org/protobuf/types/known/timestamppb.Now": modelNotNil,
+	"google.golang.org/protobuf/types/known/timestamppb.New": modelNotNil,
+	"google.golang.org/protobuf/types/known/durationpb.New":  modelNotNil,
+
+	"google.golang.org/protobuf/types/known/wrapperspb.Bool":   modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.Int32":  modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.Int64":  modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.UInt32": modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.UInt64": modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.Float":  modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.Double": modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.String": modelNotNil,
+	"google.golang.org/protobuf/types/known/wrapperspb.Bytes":  modelNotNil,
+
+	"google.golang.org/protobuf/types/known/structpb.NewStruct":      modelNilOnError,
+	"google.golang.org/protobuf/types/known/structpb.NewList":        modelNilOnError,
+	"google.golang.org/protobuf/types/known/structpb.NewValue":       modelNilOnError,
+	"google.golang.org/protobuf/types/known/structpb.NewNullValue":   modelNotNil,
+	"google.golang.org/protobuf/types/known/structpb.NewBoolValue":   modelNotNil,
+	"google.golang.org/protobuf/types/known/structpb.NewNumberValue": modelNotNil,
+	"google.golang.org/protobuf/types/known/structpb.NewStringValue": modelNotNil,
+	"google.golang.org/protobuf/types/known/structpb.NewStructValue": modelNotNil,
+	"google.golang.org/protobuf/types/known/structpb.NewListValue":   modelNotNil,
+
+	"google.golang.org/protobuf/types/known/anypb.New":             modelNilOnError,
+	"google.golang.org/protobuf/types/known/fieldmaskpb.New":       modelNilOnError,
+	"google.golang.org/protobuf/types/known/fieldmaskpb.Union":     modelNotNil,
+	"google.golang.org/protobuf/types/known/fieldmaskpb.Intersect": modelNotNil,
+
+	// Helpers of the legacy github.com/golang/protobuf module, which
+	// genproto-based code still uses.
+	"github.com/golang/protobuf/proto.Clone":          modelFirstArg,
+	"github.com/golang/protobuf/proto.Bool":           modelNotNil,
+	"github.com/golang/protobuf/proto.Int32":          modelNotNil,
+	"github.com/golang/protobuf/proto.Int64":          modelNotNil,
+	"github.com/golang/protobuf/proto.Uint32":         modelNotNil,
+	"github.com/golang/protobuf/proto.Uint64":         modelNotNil,
+	"github.com/golang/protobuf/proto.Float32":        modelNotNil,
+	"github.com/golang/protobuf/proto.Float64":        modelNotNil,
+	"github.com/golang/protobuf/proto.String":         modelNotNil,
+	"github.com/golang/protobuf/ptypes.TimestampNow":  modelNotNil,
+	"github.com/golang/protobuf/ptypes.DurationProto": modelNotNil,
+	"github.com/golang/protobuf/ptypes.MarshalAny":    modelNilOnError,
+}
+
+// codeModels lists the modeled functions that return nil exactly when their
+// first argument is the code OK, whose value is zero.
+var codeModels = map[string]bool{
+	"google.golang.org/grpc/status.Error":  true,
+	"google.golang.org/grpc/status.Errorf": true,
+}
+
+// codeModelFact returns NotNil for a call of a code model with a constant
+// code other than OK, e.g. status.Error(codes.NotFound, msg).
+func codeModelFact(call *ssa.Call) (nilFact, bool) {
+	fn := call.Call.StaticCallee()
+	if fn == nil || len(call.Call.Args) == 0 {
+		return nilFact{}, false
+	}
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || !codeModels[obj.FullName()] {
+		return nilFact{}, false
+	}
+	c, ok := call.Call.Args[0].(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.Int || constant.Sign(c.Value) == 0 {
+		return nilFact{}, false
+	}
+	return nilFact{Status: NilStatusNotNil}, true
+}
+
+// modelSummary returns the built-in summary of fn, or nil if fn is not
+// modeled.
+func modelSummary(fn *ssa.Function) *FuncSummary {
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		return nil
+	}
+	model, ok := models[obj.FullName()]
+	if !ok {
+		return nil
+	}
+	s := *model
+	s.Source = SummaryFromModel
+	return &s
+}
@@ -2,151 +2,599 @@ package analyzer
 
 import (
 	"go/token"
+	"go/types"
 
 	"golang.org/x/tools/go/ssa"
 )
 
 // NilFlowAnalyzer performs a lightweight, conservative nil-flow analysis over SSA values.
 type NilFlowAnalyzer struct {
-	visited     map[ssa.Value]NilStatus
-	funcSummary map[*ssa.Function]NilStatus
+	// BodilessDefault is the result status assumed for callees without an
+	// SSA body (other packages, assembly, linkname) that cannot be loaded.
+	BodilessDefault NilStatus
+	// FieldLoadDefault is the status of a field loaded from a base that is
+	// not a tracked local allocation, e.g. a parameter or call result.
+	FieldLoadDefault NilStatus
+	// Funcs lists the functions of the analyzed package. Package variables
+	// and fields of the package's own types are proven non-nil from the
+	// stores into them found here.
+	Funcs []*ssa.Function
+	// Contracts holds the nullability contracts declared by directives on
+	// functions and struct fields, keyed by *types.Func and field *types.Var.
+	Contracts map[types.Object]*Contract
+	// DynamicCallees optionally resolves interface method and function
+	// value calls to their possible implementations.
+	DynamicCallees func(ssa.CallInstruction) []*ssa.Function
+	// ImportSummary optionally returns a summary computed for a bodiless
+	// callee when its own package was analyzed, e.g. from analysis facts.
+	ImportSummary func(*ssa.Function) *FuncSummary
+	// ImportContract optionally returns the contract declared on a function
+	// or field of another package, e.g. from analysis facts.
+	ImportContract func(types.Object) *Contract
+	// NoReturn lists the functions that never return, keyed by
+	// types.Func.FullName, in addition to those inferred from their bodies.
+	NoReturn map[string]bool
+
+	visited     *valueCache
+	pending     map[ssa.Value]*pendingFact
+	funcSummary map[*ssa.Function]*FuncSummary
+	globals     map[*ssa.Global]NilStatus
+	fields      map[fieldKey]NilStatus
+	dead        map[*ssa.Function]map[*ssa.BasicBlock]bool
+	// tracing holds the struct copies and closure calls being traced by
+	// fieldLoadFact, to break cycles between variables and loops.
+	tracing map[ssa.Instruction]bool
 }
 
+// maxSCCIterations bounds the fixpoint iteration over a recursive SCC.
+// Summaries are joined monotonically, so the bound is only a safety net.
+const maxSCCIterations = 32
+
 // NewNilFlowAnalyzer constructs a new NilFlowAnalyzer.
 func NewNilFlowAnalyzer() *NilFlowAnalyzer {
 	return &NilFlowAnalyzer{
-		visited:     make(map[ssa.Value]NilStatus),
-		funcSummary: make(map[*ssa.Function]NilStatus),
+		BodilessDefault:  NilStatusUnknown,
+		FieldLoadDefault: NilStatusUnknown,
+		visited:          newValueCache(),
+		pending:          make(map[ssa.Value]*pendingFact),
+		funcSummary:      make(map[*ssa.Function]*FuncSummary),
+		globals:          make(map[*ssa.Global]NilStatus),
+		NoReturn:         defaultNoReturn(),
+		fields:           make(map[fieldKey]NilStatus),
+		dead:             make(map[*ssa.Function]map[*ssa.BasicBlock]bool),
+		tracing:          make(map[ssa.Instruction]bool),
 	}
 }
 
 // Reset clears internal caches between analyses.
 func (a *NilFlowAnalyzer) Reset() {
-	for k := range a.visited {
-		delete(a.visited, k)
-	}
+	a.visited.truncate(0)
 	// funcSummary is kept across Resets; summaries are per-function and
 	// can be reused across handlers.
 }
 
-// ValueNilStatus computes a conservative nil-status for v.
+// ValueNilStatus computes a conservative nil-status for v. Parameters of the
+// function containing v are treated as Unknown.
 func (a *NilFlowAnalyzer) ValueNilStatus(v ssa.Value) NilStatus {
 	if v == nil {
 		return NilStatusUnknown
 	}
-	if s, ok := a.visited[v]; ok {
-		return s
+	return a.valueFact(v).resolve()
+}
+
+// valueFact computes the nil fact of v relative to the parameters of its
+// enclosing function. Values depending on themselves, e.g. through the back
+// edge of a loop, are iterated to a fixpoint starting from bottom, so a
+// pointer that is non-nil on every iteration is proven non-nil.
+func (a *NilFlowAnalyzer) valueFact(v ssa.Value) nilFact {
+	if f, ok := a.visited.get(v); ok {
+		return f
+	}
+	if p, ok := a.pending[v]; ok {
+		if p.cache != a.visited {
+			// Reached from a summary being computed, which must not
+			// depend on a provisional fact.
+			return nilFact{Status: NilStatusUnknown}
+		}
+		p.cyclic = true
+		return p.approx
 	}
-	// Mark as unknown to break cycles.
-	a.visited[v] = NilStatusUnknown
 
+	p := &pendingFact{approx: nilFact{Status: nilStatusBottom}, cache: a.visited}
+	a.pending[v] = p
+	defer delete(a.pending, v)
+	start := a.visited.len()
+	for i := 0; ; i++ {
+		f := a.computeValueFact(v).join(p.approx)
+		if !p.cyclic || f == p.approx {
+			a.visited.put(v, f)
+			return f
+		}
+		if i == maxFixpointIterations {
+			f = nilFact{Status: NilStatusUnknown}
+			a.visited.put(v, f)
+			return f
+		}
+		// Recompute with the new approximation, dropping the facts
+		// derived from the previous one.
+		p.approx, p.cyclic = f, false
+		a.visited.truncate(start)
+	}
+}
+
+// computeValueFact computes one approximation of the fact of v, using the
+// current approximations of the values being computed.
+func (a *NilFlowAnalyzer) computeValueFact(v ssa.Value) nilFact {
+	var f nilFact
 	switch val := v.(type) {
 	case *ssa.Const:
 		if val.IsNil() {
-			a.visited[v] = NilStatusDefinitelyNil
+			f.Status = NilStatusDefinitelyNil
 		} else {
-			a.visited[v] = NilStatusNotNil
+			f.Status = NilStatusNotNil
 		}
 	case *ssa.Alloc:
 		// New allocations are never nil.
-		a.visited[v] = NilStatusNotNil
+		f.Status = NilStatusNotNil
+	case *ssa.Parameter:
+		f = a.paramFact(val)
+	case *ssa.FreeVar:
+		f = freeVarFact(val)
+	case *ssa.MakeClosure, *ssa.Function:
+		// Function values built from a body are never nil.
+		f.Status = NilStatusNotNil
 	case *ssa.MakeInterface:
-		a.visited[v] = a.ValueNilStatus(val.X)
+		f = a.valueFact(val.X)
 	case *ssa.ChangeInterface:
-		a.visited[v] = a.ValueNilStatus(val.X)
+		f = a.valueFact(val.X)
 	case *ssa.Phi:
-		status := NilStatusNotNil
-		for _, edge := range val.Edges {
-			edgeStatus := a.ValueNilStatus(edge)
-			status = joinNilStatus(status, edgeStatus)
-			if status == NilStatusMaybeNil || status == NilStatusDefinitelyNil {
+		// Edges from blocks ending in a call that never returns carry
+		// nothing.
+		f.Status = nilStatusBottom
+		for i, edge := range val.Edges {
+			pred := val.Block().Preds[i]
+			if a.isDead(pred) {
+				continue
+			}
+			f = f.join(a.edgeFact(edge, pred, val.Block()))
+			if f.Status == NilStatusMaybeNil || f.Status == NilStatusDefinitelyNil {
 				break
 			}
 		}
-		a.visited[v] = status
 	case *ssa.UnOp:
-		// For *ptr, propagate ptr's nil status.
-		if val.Op == token.MUL {
-			a.visited[v] = a.ValueNilStatus(val.X)
+		// Loads of local variables and fields are traced to the stores
+		// into them, receives to the sends on the channel.
+		switch val.Op {
+		case token.MUL:
+			f = a.fieldLoadFact(val.X, nil, val)
+		case token.ARROW:
+			f, _ = a.recvFact(val, nil)
+		default:
+			f.Status = NilStatusUnknown
 		}
+	case *ssa.Field:
+		f = a.structFieldFact(val)
 	case *ssa.Call:
-		a.visited[v] = a.callNilStatus(val)
+		f = a.callFact(val)
+	case *ssa.TypeAssert:
+		f = a.assertedFact(val)
+	case *ssa.Lookup:
+		f = a.lookupFact(val)
+	case *ssa.Extract:
+		// Only the first result of a call is summarized. A failed comma-ok
+		// assertion yields the zero value.
+		switch tuple := val.Tuple.(type) {
+		case *ssa.Call:
+			if val.Index == 0 {
+				f = a.callFact(tuple)
+			} else {
+				f.Status = NilStatusUnknown
+			}
+		case *ssa.TypeAssert:
+			if val.Index == 0 {
+				f = zeroFact(tuple.AssertedType).join(a.assertedFact(tuple))
+			} else {
+				f.Status = NilStatusNotNil
+			}
+		case *ssa.Lookup:
+			if val.Index == 0 {
+				f = a.lookupFact(tuple)
+			} else {
+				f.Status = NilStatusNotNil
+			}
+		case *ssa.Next:
+			if val.Index == 2 {
+				f = a.rangeValueFact(tuple)
+			} else {
+				f.Status = NilStatusNotNil
+			}
+		case *ssa.UnOp, *ssa.Select:
+			if rf, ok := a.recvFact(val, nil); ok {
+				f = rf
+			} else {
+				f.Status = NilStatusNotNil
+			}
+		default:
+			f.Status = NilStatusUnknown
+		}
 	default:
 		// Unknown instruction kinds are treated as unknown.
-		a.visited[v] = NilStatusUnknown
+		f.Status = NilStatusUnknown
 	}
+	return f
+}
 
-	return a.visited[v]
+// paramFact returns the fact of a parameter: its declared status if it has
+// a contract, otherwise nil exactly when the caller's argument is.
+func (a *NilFlowAnalyzer) paramFact(p *ssa.Parameter) nilFact {
+	fn := p.Parent()
+	c := a.contractOf(fn)
+	for i, param := range fn.Params {
+		if param != p {
+			continue
+		}
+		if s, ok := c.param(i); ok {
+			return nilFact{Status: s}
+		}
+		if i < maxTrackedParams {
+			return nilFact{Status: nilStatusBottom, Params: 1 << i}
+		}
+	}
+	return nilFact{Status: NilStatusUnknown}
 }
 
-// callNilStatus summarizes the nil behavior of a call expression.
-// For now we use a simple, conservative summary: if all return sites in the
-// callee return a fresh allocation or a non-nil constant, we treat the result
-// as NotNil. If any return site is nil or unknown, we treat it as MaybeNil.
-func (a *NilFlowAnalyzer) callNilStatus(call *ssa.Call) NilStatus {
-	if call == nil {
-		return NilStatusUnknown
+// assertedFact returns the fact of the result of a successful type
+// assertion. Asserting a nil interface panics, so the result is nil only if
+// the interface holds a typed nil, which is visible when the interface was
+// built from a tracked value, directly or returned by an analyzed or
+// modeled callee, e.g. proto.Clone(maybe()).(*pb.Profile).
+func (a *NilFlowAnalyzer) assertedFact(ta *ssa.TypeAssert) nilFact {
+	x := ta.X
+	for {
+		switch v := x.(type) {
+		case *ssa.MakeInterface:
+			return a.valueFact(v.X)
+		case *ssa.ChangeInterface:
+			x = v.X
+			continue
+		case *ssa.TypeAssert:
+			if !v.CommaOk {
+				x = v.X
+				continue
+			}
+		case *ssa.Call:
+			if a.analyzedCall(v) {
+				return a.valueFact(v)
+			}
+		}
+		return nilFact{Status: NilStatusNotNil}
 	}
-	common := call.Common()
-	if common == nil {
-		return NilStatusUnknown
+}
+
+// freeVarFact returns the fact of a closure's free variable: nil exactly
+// when the value bound by MakeClosure is. Free variables are numbered after
+// the closure's parameters.
+func freeVarFact(fv *ssa.FreeVar) nilFact {
+	fn := fv.Parent()
+	for i, v := range fn.FreeVars {
+		if j := len(fn.Params) + i; v == fv && j < maxTrackedParams {
+			return nilFact{Status: nilStatusBottom, Params: 1 << j}
+		}
 	}
-	fn := common.StaticCallee()
-	if fn == nil {
-		return NilStatusUnknown
+	return nilFact{Status: NilStatusUnknown}
+}
+
+// callFact instantiates the callee's summary with the facts of the call's
+// arguments. Dynamic calls join the summaries of all possible callees.
+func (a *NilFlowAnalyzer) callFact(call *ssa.Call) nilFact {
+	if f, ok := a.getterFact(call); ok {
+		return f
+	}
+	if f, ok := codeModelFact(call); ok {
+		return f
+	}
+	return a.joinCallees(call, func(s *FuncSummary) *FuncSummary { return s })
+}
+
+// successFact is callFact for a call whose error result is known to be nil.
+func (a *NilFlowAnalyzer) successFact(call *ssa.Call) nilFact {
+	return a.joinCallees(call, func(s *FuncSummary) *FuncSummary {
+		if s.OnSuccess != nil {
+			return s.OnSuccess
+		}
+		return s
+	})
+}
+
+// joinCallees joins, over all possible callees of call, the summary chosen
+// by pick instantiated with the call's arguments. A call without known
+// callees is Unknown.
+func (a *NilFlowAnalyzer) joinCallees(call *ssa.Call, pick func(*FuncSummary) *FuncSummary) nilFact {
+	callees := a.callees(call)
+	if len(callees) == 0 {
+		return nilFact{Status: NilStatusUnknown}
+	}
+	args := callArgs(call)
+	f := nilFact{Status: nilStatusBottom}
+	for _, fn := range callees {
+		f = f.join(a.instantiate(pick(a.Summary(fn)).fact(), args, call))
+	}
+	return f
+}
+
+// callArgs returns the values bound to the callee's parameters, followed by
+// the free variables of a closure.
+func callArgs(call *ssa.Call) []ssa.Value {
+	return commonArgs(&call.Call)
+}
+
+// commonArgs is callArgs for any call, go or defer instruction.
+func commonArgs(c *ssa.CallCommon) []ssa.Value {
+	args := c.Args
+	if mc, ok := c.Value.(*ssa.MakeClosure); ok {
+		// Bindings follow the parameters, see freeVarFact.
+		return append(append([]ssa.Value(nil), args...), mc.Bindings...)
+	}
+	if c.IsInvoke() {
+		// Concrete methods take the receiver as their first parameter.
+		return append([]ssa.Value{c.Value}, args...)
+	}
+	return args
+}
+
+// callees returns the functions call may invoke: its static callee, or the
+// implementations reported by DynamicCallees.
+func (a *NilFlowAnalyzer) callees(call ssa.CallInstruction) []*ssa.Function {
+	if fn := call.Common().StaticCallee(); fn != nil {
+		return []*ssa.Function{fn}
+	}
+	if a.DynamicCallees == nil {
+		return nil
+	}
+	return a.DynamicCallees(call)
+}
+
+// analyzedCall reports whether every callee of call is known and
+// summarized from its body, a model or a contract.
+func (a *NilFlowAnalyzer) analyzedCall(call *ssa.Call) bool {
+	callees := a.callees(call)
+	for _, fn := range callees {
+		if a.Summary(fn).Source == SummaryNotAnalyzed {
+			return false
+		}
+	}
+	return len(callees) > 0
+}
+
+// instantiate replaces the callee parameters f depends on by the facts of
+// the corresponding arguments at the call site. Missing arguments are unknown.
+func (a *NilFlowAnalyzer) instantiate(f nilFact, args []ssa.Value, call ssa.Instruction) nilFact {
+	out := nilFact{Status: f.Status}
+	for _, i := range f.Params.indices() {
+		if i >= len(args) || args[i] == nil {
+			return nilFact{Status: NilStatusUnknown}
+		}
+		out = out.join(a.factAt(args[i], call))
 	}
+	return out
+}
 
-	// Reuse cached summary if available.
+// Summary returns the parameter-relative nil summary of fn's first result.
+// Return values are analyzed with the full ValueNilStatus machinery, so
+// callees are summarized transitively. Mutually recursive functions are
+// solved together by iterating over their call-graph SCC to a fixpoint.
+// Summaries are computed once per callee and cached across Resets.
+func (a *NilFlowAnalyzer) Summary(fn *ssa.Function) *FuncSummary {
+	// Reuse cached summary if available. While an SCC is being solved its
+	// members hold their current approximation.
 	if s, ok := a.funcSummary[fn]; ok {
 		return s
 	}
+	for _, scc := range a.calleeSCCs(fn) {
+		a.solveSCC(scc)
+	}
+	return a.funcSummary[fn]
+}
+
+// solveSCC computes the summaries of a set of mutually recursive functions.
+// All callees outside the SCC are already summarized. Each member starts at
+// bottom and is re-analyzed until no summary changes.
+func (a *NilFlowAnalyzer) solveSCC(scc []*ssa.Function) {
+	if len(scc) == 1 {
+		if s := a.contractSummary(scc[0]); s != nil {
+			a.funcSummary[scc[0]] = s
+			return
+		}
+		if s := modelSummary(scc[0]); s != nil {
+			a.funcSummary[scc[0]] = s
+			return
+		}
+		if len(scc[0].Blocks) == 0 {
+			a.funcSummary[scc[0]] = a.bodilessSummary(scc[0])
+			return
+		}
+	}
+
+	for _, fn := range scc {
+		s := &FuncSummary{Result: nilStatusBottom}
+		if errorResultIndex(fn.Signature) >= 0 {
+			s.OnSuccess = &FuncSummary{Result: nilStatusBottom}
+		}
+		a.funcSummary[fn] = s
+	}
+
+	recursive := len(scc) > 1 || a.isSelfRecursive(scc[0])
+	for iter := 0; iter < maxSCCIterations; iter++ {
+		changed := false
+		for _, fn := range scc {
+			// Dead blocks depend on the summaries of the members.
+			delete(a.dead, fn)
+		}
+		for _, fn := range scc {
+			old := a.funcSummary[fn]
+			result, success := a.returnFact(fn)
+			result = result.join(old.fact())
+			success = success.join(old.successFact())
+			noReturn := !a.returnsNormally(fn)
+			if result != old.fact() || success != old.successFact() || noReturn != old.NoReturn {
+				s := result.summary()
+				if old.OnSuccess != nil {
+					s.OnSuccess = success.summary()
+				}
+				s.NoReturn = noReturn
+				a.funcSummary[fn] = s
+				changed = true
+			}
+		}
+		if !changed || !recursive {
+			break
+		}
+	}
+	for _, fn := range scc {
+		a.funcSummary[fn].Sets = a.fieldSets(fn)
+	}
+}
+
+// bodilessSummary summarizes a function without an SSA body. Summaries
+// computed for its own package are imported when possible; otherwise
+// BodilessDefault is assumed and the summary is marked as not analyzed.
+func (a *NilFlowAnalyzer) bodilessSummary(fn *ssa.Function) *FuncSummary {
+	if a.ImportSummary != nil {
+		if s := a.ImportSummary(fn); s != nil {
+			return s
+		}
+	}
+	return &FuncSummary{Result: a.BodilessDefault, Source: SummaryNotAnalyzed}
+}
 
-	status := NilStatusNotNil
+// returnFact joins the facts of fn's first result over all return sites,
+// and separately over the return sites where fn's error result may be nil.
+// Values are evaluated in a private cache, since they may depend on
+// provisional summaries that are still changing.
+func (a *NilFlowAnalyzer) returnFact(fn *ssa.Function) (result, success nilFact) {
+	saved := a.visited
+	a.visited = newValueCache()
+	defer func() { a.visited = saved }()
 
-	// Inspect all return sites of the callee.
+	errIndex := errorResultIndex(fn.Signature)
+	result = nilFact{Status: nilStatusBottom}
+	success = nilFact{Status: nilStatusBottom}
 	for _, b := range fn.Blocks {
 		for _, instr := range b.Instrs {
 			ret, ok := instr.(*ssa.Return)
-			if !ok || len(ret.Results) == 0 {
+			if !ok || len(ret.Results) == 0 || a.isDead(b) {
 				continue
 			}
+			f := a.factAt(ret.Results[0], ret)
+			result = result.join(f)
+			if errIndex < 0 {
+				continue
+			}
+			if errFact := a.factAt(ret.Results[errIndex], ret); errFact != (nilFact{Status: NilStatusNotNil}) {
+				success = success.join(f)
+			}
+		}
+	}
+	return result, success
+}
 
-			rv := ret.Results[0]
-			switch rvt := rv.(type) {
-			case *ssa.Alloc:
-				// Fresh allocation is non-nil.
-				status = joinNilStatus(status, NilStatusNotNil)
-			case *ssa.Const:
-				if rvt.IsNil() {
-					status = joinNilStatus(status, NilStatusDefinitelyNil)
-				} else {
-					status = joinNilStatus(status, NilStatusNotNil)
-				}
-			default:
-				// For complex expressions (Phi, nested calls, etc.) we are conservative.
-				status = joinNilStatus(status, NilStatusMaybeNil)
+// calleeSCCs returns the strongly connected components of the call
+// graph reachable from root, restricted to functions without a summary, in
+// reverse topological order (callees before callers).
+func (a *NilFlowAnalyzer) calleeSCCs(root *ssa.Function) [][]*ssa.Function {
+	var (
+		index   = make(map[*ssa.Function]int)
+		lowlink = make(map[*ssa.Function]int)
+		onStack = make(map[*ssa.Function]bool)
+		stack   []*ssa.Function
+		sccs    [][]*ssa.Function
+	)
+
+	var connect func(fn *ssa.Function)
+	connect = func(fn *ssa.Function) {
+		index[fn] = len(index)
+		lowlink[fn] = index[fn]
+		stack = append(stack, fn)
+		onStack[fn] = true
+
+		for _, callee := range a.calledFunctions(fn) {
+			if _, done := a.funcSummary[callee]; done {
+				continue
 			}
+			if _, seen := index[callee]; !seen {
+				connect(callee)
+				lowlink[fn] = min(lowlink[fn], lowlink[callee])
+			} else if onStack[callee] {
+				lowlink[fn] = min(lowlink[fn], index[callee])
+			}
+		}
 
-			if status == NilStatusMaybeNil || status == NilStatusDefinitelyNil {
-				break
+		if lowlink[fn] == index[fn] {
+			var scc []*ssa.Function
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				scc = append(scc, top)
+				if top == fn {
+					break
+				}
 			}
+			sccs = append(sccs, scc)
 		}
-		if status == NilStatusMaybeNil || status == NilStatusDefinitelyNil {
-			break
+	}
+	connect(root)
+	return sccs
+}
+
+// calledFunctions lists the distinct callees of the calls in fn. Modeled
+// and contracted functions are summarized without looking at their bodies.
+func (a *NilFlowAnalyzer) calledFunctions(fn *ssa.Function) []*ssa.Function {
+	if modelSummary(fn) != nil || a.contractSummary(fn) != nil {
+		return nil
+	}
+	var out []*ssa.Function
+	seen := make(map[*ssa.Function]bool)
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			call, ok := instr.(*ssa.Call)
+			if !ok {
+				continue
+			}
+			for _, callee := range a.callees(call) {
+				if !seen[callee] {
+					seen[callee] = true
+					out = append(out, callee)
+				}
+			}
 		}
 	}
+	return out
+}
 
-	a.funcSummary[fn] = status
-	return status
+func (a *NilFlowAnalyzer) isSelfRecursive(fn *ssa.Function) bool {
+	for _, callee := range a.calledFunctions(fn) {
+		if callee == fn {
+			return true
+		}
+	}
+	return false
 }
 
 // joinNilStatus merges two NilStatus values conservatively.
 func joinNilStatus(a, b NilStatus) NilStatus {
 	switch {
+	case a == nilStatusBottom:
+		return b
+	case b == nilStatusBottom:
+		return a
 	case a == NilStatusUnknown || b == NilStatusUnknown:
 		if a == NilStatusDefinitelyNil || b == NilStatusDefinitelyNil {
 			return NilStatusMaybeNil
 		}
+		if a == NilStatusMaybeNil || b == NilStatusMaybeNil {
+			return NilStatusMaybeNil
+		}
 		return NilStatusUnknown
 	case a == NilStatusDefinitelyNil && b == NilStatusDefinitelyNil:
 		return NilStatusDefinitelyNil
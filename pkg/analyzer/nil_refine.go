@@ -0,0 +1,238 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// StatusAt computes the nil-status of v at the program point of instr,
+// refined by dominating comparisons of v against nil. Chains of && and ||
+// and early returns are lowered by SSA into nested branches, so they are
+// covered by walking the dominator tree.
+func (a *NilFlowAnalyzer) StatusAt(v ssa.Value, instr ssa.Instruction) NilStatus {
+	if v == nil {
+		return NilStatusUnknown
+	}
+	return a.factAt(v, instr).resolve()
+}
+
+// IsMaybeNilAt reports whether v could be nil (including unknown cases) at
+// the program point of instr.
+func (a *NilFlowAnalyzer) IsMaybeNilAt(v ssa.Value, instr ssa.Instruction) bool {
+	s := a.StatusAt(v, instr)
+	return s == NilStatusMaybeNil || s == NilStatusDefinitelyNil || s == NilStatusUnknown
+}
+
+// NilReason returns a short explanation of why v may be nil at instr, e.g.
+// "value from map lookup may be missing" or "User.Profile may be unset", or
+// "" when there is none.
+func (a *NilFlowAnalyzer) NilReason(v ssa.Value, instr ssa.Instruction) string {
+	for {
+		switch x := v.(type) {
+		case *ssa.MakeInterface:
+			v = x.X
+			continue
+		case *ssa.ChangeInterface:
+			v = x.X
+			continue
+		}
+		break
+	}
+	var edges []edge
+	if instr != nil && instr.Block() != nil {
+		edges = a.takenEdges(instr.Block())
+	}
+	if lookupMayMiss(v, edges) {
+		return "value from map lookup may be missing"
+	}
+	return a.unsetFieldReason(v, instr)
+}
+
+// factAt returns the fact of v at instr: the branch outcome if a dominating
+// condition decides v's nilness, otherwise v's flow-insensitive fact.
+func (a *NilFlowAnalyzer) factAt(v ssa.Value, instr ssa.Instruction) nilFact {
+	if instr == nil || instr.Block() == nil {
+		return a.valueFact(v)
+	}
+	return a.refinedFact(v, a.takenEdges(instr.Block()))
+}
+
+// edgeFact returns the fact of the Phi operand v flowing along the edge
+// from pred into succ.
+func (a *NilFlowAnalyzer) edgeFact(v ssa.Value, pred, succ *ssa.BasicBlock) nilFact {
+	return a.refinedFact(v, append([]edge{{pred, succ}}, a.takenEdges(pred)...))
+}
+
+// refinedFact applies the knowledge that every branch in edges was taken to
+// v. Besides checks of v itself, a first call result is refined by a check
+// showing the call's error result to be nil, and a comma-ok type assertion,
+// map lookup or channel receive by a check of its ok result.
+func (a *NilFlowAnalyzer) refinedFact(v ssa.Value, edges []edge) nilFact {
+	if s, ok := edgesStatus(v, edges); ok {
+		return nilFact{Status: s}
+	}
+	if ext, ok := v.(*ssa.Extract); ok && ext.Index == 0 {
+		switch tuple := ext.Tuple.(type) {
+		case *ssa.Call:
+			for _, errVal := range errorExtracts(tuple) {
+				if s, ok := edgesStatus(errVal, edges); ok && s == NilStatusDefinitelyNil {
+					return a.successFact(tuple)
+				}
+			}
+		case *ssa.TypeAssert:
+			for _, ref := range *tuple.Referrers() {
+				if okVal, isExt := ref.(*ssa.Extract); isExt && okVal.Index == 1 {
+					if holds, ok := edgesHold(okVal, edges); ok && holds {
+						return a.assertedFact(tuple)
+					}
+				}
+			}
+		case *ssa.Lookup:
+			if !lookupMayMiss(ext, edges) {
+				return a.elementFact(tuple.X)
+			}
+		}
+	}
+	if f, ok := a.recvFact(v, edges); ok {
+		return f
+	}
+	return a.valueFact(v)
+}
+
+// edge is a control-flow edge from a branching block into a successor.
+type edge struct {
+	pred, succ *ssa.BasicBlock
+}
+
+// takenEdges lists, innermost first, the edges taken by every path to b:
+// the entries of b and its dominators that have a single predecessor whose
+// end is reached, e.g. the other branch of an if calling log.Fatal.
+func (a *NilFlowAnalyzer) takenEdges(b *ssa.BasicBlock) []edge {
+	var edges []edge
+	for d := b; d != nil; d = d.Idom() {
+		var live []*ssa.BasicBlock
+		for _, pred := range d.Preds {
+			if !a.isDead(pred) {
+				live = append(live, pred)
+			}
+		}
+		if len(live) == 1 {
+			edges = append(edges, edge{live[0], d})
+		}
+	}
+	return edges
+}
+
+// edgesStatus returns the status of v decided by the innermost edge that
+// branches on a nil check of v.
+func edgesStatus(v ssa.Value, edges []edge) (NilStatus, bool) {
+	for _, e := range edges {
+		if s, ok := branchStatus(v, e.pred, e.succ); ok {
+			return s, true
+		}
+	}
+	return NilStatusUnknown, false
+}
+
+// edgesHold returns the value of the boolean v decided by the innermost
+// edge that branches on v or !v.
+func edgesHold(v ssa.Value, edges []edge) (bool, bool) {
+	for _, e := range edges {
+		if holds, ok := branchHolds(v, e.pred, e.succ); ok {
+			return holds, true
+		}
+	}
+	return false, false
+}
+
+// branchHolds reports the value of the boolean v on the edge from pred to
+// succ when pred ends in an If on v or !v.
+func branchHolds(v ssa.Value, pred, succ *ssa.BasicBlock) (bool, bool) {
+	ifInstr := branchIf(pred)
+	if ifInstr == nil {
+		return false, false
+	}
+	taken := succ == pred.Succs[0]
+	switch cond := ifInstr.Cond.(type) {
+	case *ssa.UnOp:
+		if cond.Op == token.NOT && cond.X == v {
+			return !taken, true
+		}
+	default:
+		if cond == v {
+			return taken, true
+		}
+	}
+	return false, false
+}
+
+// branchIf returns the If ending b if it branches to two distinct blocks.
+func branchIf(b *ssa.BasicBlock) *ssa.If {
+	if len(b.Instrs) == 0 || len(b.Succs) != 2 || b.Succs[0] == b.Succs[1] {
+		return nil
+	}
+	ifInstr, _ := b.Instrs[len(b.Instrs)-1].(*ssa.If)
+	return ifInstr
+}
+
+// errorResultIndex returns the index of sig's trailing error result, or -1
+// when sig does not return a value followed by an error.
+func errorResultIndex(sig *types.Signature) int {
+	n := sig.Results().Len()
+	if n < 2 || !types.Identical(sig.Results().At(n-1).Type(), errorType) {
+		return -1
+	}
+	return n - 1
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// errorExtracts returns the values extracting call's trailing error result.
+func errorExtracts(call *ssa.Call) []ssa.Value {
+	idx := errorResultIndex(call.Call.Signature())
+	if idx < 0 {
+		return nil
+	}
+	var out []ssa.Value
+	for _, ref := range *call.Referrers() {
+		if ext, ok := ref.(*ssa.Extract); ok && ext.Index == idx {
+			out = append(out, ext)
+		}
+	}
+	return out
+}
+
+// branchStatus reports the status of v on the edge from pred to succ when
+// pred ends in an If comparing v against nil.
+func branchStatus(v ssa.Value, pred, succ *ssa.BasicBlock) (NilStatus, bool) {
+	ifInstr := branchIf(pred)
+	if ifInstr == nil {
+		return NilStatusUnknown, false
+	}
+	x, op, ok := nilComparison(ifInstr.Cond)
+	if !ok || x != v {
+		return NilStatusUnknown, false
+	}
+	if (op == token.EQL) == (succ == pred.Succs[0]) {
+		return NilStatusDefinitelyNil, true
+	}
+	return NilStatusNotNil, true
+}
+
+// nilComparison matches cond against x == nil or x != nil, in either
+// operand order.
+func nilComparison(cond ssa.Value) (ssa.Value, token.Token, bool) {
+	bin, ok := cond.(*ssa.BinOp)
+	if !ok || (bin.Op != token.EQL && bin.Op != token.NEQ) {
+		return nil, 0, false
+	}
+	switch {
+	case isNilConst(bin.Y):
+		return bin.X, bin.Op, true
+	case isNilConst(bin.X):
+		return bin.Y, bin.Op, true
+	}
+	return nil, 0, false
+}
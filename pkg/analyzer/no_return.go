@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// noReturnFuncs lists the functions known never to return, keyed by
+// types.Func.FullName. Methods of testing.T and testing.B are promoted from
+// testing.common.
+var noReturnFuncs = []string{
+	"os.Exit",
+	"runtime.Goexit",
+	"log.Fatal",
+	"log.Fatalf",
+	"log.Fatalln",
+	"log.Panic",
+	"log.Panicf",
+	"log.Panicln",
+	"(*log.Logger).Fatal",
+	"(*log.Logger).Fatalf",
+	"(*log.Logger).Fatalln",
+	"(*log.Logger).Panic",
+	"(*log.Logger).Panicf",
+	"(*log.Logger).Panicln",
+	"(*testing.common).Fatal",
+	"(*testing.common).Fatalf",
+	"(*testing.common).FailNow",
+	"(*testing.common).Skip",
+	"(*testing.common).Skipf",
+	"(*testing.common).SkipNow",
+}
+
+// defaultNoReturn returns the set of noReturnFuncs.
+func defaultNoReturn() map[string]bool {
+	m := make(map[string]bool, len(noReturnFuncs))
+	for _, name := range noReturnFuncs {
+		m[name] = true
+	}
+	return m
+}
+
+// neverReturns reports whether call invokes a function that never returns:
+// one listed in NoReturn, or one whose summary says so, such as a helper
+// that always panics.
+func (a *NilFlowAnalyzer) neverReturns(call *ssa.Call) bool {
+	fn := call.Call.StaticCallee()
+	if fn == nil {
+		return false
+	}
+	obj, _ := fn.Object().(*types.Func)
+	if origin := fn.Origin(); origin != nil {
+		obj, _ = origin.Object().(*types.Func)
+	}
+	if obj != nil && a.NoReturn[obj.FullName()] {
+		return true
+	}
+	return a.Summary(fn).NoReturn
+}
+
+// deadBlocks returns the blocks of fn whose end is never reached: blocks
+// calling a function that never returns, blocks only reachable through
+// them, and the Recover block unless a deferred call may recover. Their
+// edges are ignored when joining Phis and return sites.
+func (a *NilFlowAnalyzer) deadBlocks(fn *ssa.Function) map[*ssa.BasicBlock]bool {
+	if dead, ok := a.dead[fn]; ok {
+		return dead
+	}
+	dead := make(map[*ssa.BasicBlock]bool)
+	a.dead[fn] = dead
+
+	stops := func(b *ssa.BasicBlock) bool {
+		for _, instr := range b.Instrs {
+			if call, ok := instr.(*ssa.Call); ok && a.neverReturns(call) {
+				return true
+			}
+		}
+		return false
+	}
+	reached := map[*ssa.BasicBlock]bool{fn.Blocks[0]: true}
+	queue := []*ssa.BasicBlock{fn.Blocks[0]}
+	if fn.Recover != nil && mayRecover(fn) {
+		reached[fn.Recover] = true
+		queue = append(queue, fn.Recover)
+	}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		if stops(b) {
+			dead[b] = true
+			continue
+		}
+		for _, succ := range b.Succs {
+			if !reached[succ] {
+				reached[succ] = true
+				queue = append(queue, succ)
+			}
+		}
+	}
+	for _, b := range fn.Blocks {
+		if !reached[b] {
+			dead[b] = true
+		}
+	}
+	return dead
+}
+
+// isDead reports whether the end of b is never reached.
+func (a *NilFlowAnalyzer) isDead(b *ssa.BasicBlock) bool {
+	return a.deadBlocks(b.Parent())[b]
+}
+
+// returnsNormally reports whether fn has a return site that may be reached.
+func (a *NilFlowAnalyzer) returnsNormally(fn *ssa.Function) bool {
+	for _, b := range fn.Blocks {
+		if len(b.Instrs) == 0 || a.isDead(b) {
+			continue
+		}
+		if _, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok {
+			return true
+		}
+	}
+	return false
+}
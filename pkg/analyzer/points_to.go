@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// A store may write a response field through a local alias of its address:
+//
+//	p := &resp.Profile
+//	if fallback {
+//		p = &resp.Backup
+//	}
+//	*p = loadProfile()
+//
+// or through a pointer parameter of a callee, fill(&resp.Profile). The
+// points-to sets computed here are deliberately simple: addresses are
+// followed through Phis, conversions and local variables, which covers
+// the aliases SSA does not already resolve to the FieldAddr itself.
+
+// addrTargets returns the addresses addr may hold, following the local
+// aliases it flows through. Addresses that cannot be followed further,
+// such as field addresses, parameters and call results, are returned as
+// they are.
+func addrTargets(addr ssa.Value) []ssa.Value {
+	var out []ssa.Value
+	seen := make(map[ssa.Value]bool)
+	var visit func(v ssa.Value)
+	visit = func(v ssa.Value) {
+		if seen[v] {
+			return
+		}
+		seen[v] = true
+		switch v := v.(type) {
+		case *ssa.Phi:
+			for _, edge := range v.Edges {
+				visit(edge)
+			}
+			return
+		case *ssa.ChangeType:
+			visit(v.X)
+			return
+		case *ssa.UnOp:
+			// A load of a local variable holding the address, e.g. one
+			// captured by a closure.
+			if vals, ok := variableStores(v.X); ok && v.Op == token.MUL && len(vals) > 0 {
+				for _, val := range vals {
+					visit(val)
+				}
+				return
+			}
+		}
+		out = append(out, v)
+	}
+	visit(addr)
+	return out
+}
+
+// storedFields returns the field addresses a store through addr may write.
+func storedFields(addr ssa.Value) []*ssa.FieldAddr {
+	var fields []*ssa.FieldAddr
+	for _, target := range addrTargets(addr) {
+		if fa, ok := target.(*ssa.FieldAddr); ok {
+			fields = append(fields, fa)
+		}
+	}
+	return fields
+}
+
+// isMessageSlot reports whether t is a pointer to a variable holding a
+// pointer to a proto message, such as the **pb.Profile parameter of a
+// helper filling in a field of its caller.
+func isMessageSlot(t types.Type) bool {
+	ptr, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	msg := receiverNamedType(ptr.Elem())
+	_, isPtr := ptr.Elem().Underlying().(*types.Pointer)
+	return isPtr && msg != nil && implementsProtoMessage(msg)
+}
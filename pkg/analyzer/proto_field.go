@@ -4,11 +4,16 @@ import (
 	"go/types"
 	"reflect"
 	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // ProtoFieldAnalyzer inspects proto-generated Go structs and identifies risky fields.
 type ProtoFieldAnalyzer struct {
-	cache map[*types.Named]*ProtoMessageInfo
+	cache       map[*types.Named]*ProtoMessageInfo
+	descriptors *DescriptorIndex
+	mismatches  []PolicyMismatch
+	consumers   map[string]map[string]string
 }
 
 func NewProtoFieldAnalyzer() *ProtoFieldAnalyzer {
@@ -17,6 +22,68 @@ func NewProtoFieldAnalyzer() *ProtoFieldAnalyzer {
 	}
 }
 
+// UseDescriptors makes field classification follow the proto definitions in
+// idx. Struct-tag heuristics are still computed and cross-checked; any
+// disagreement is recorded as a PolicyMismatch.
+func (p *ProtoFieldAnalyzer) UseDescriptors(idx *DescriptorIndex) {
+	p.descriptors = idx
+}
+
+// MarkImplicitRequirement records that consumer code at site, e.g.
+// "client.go:12", dereferences msg.field without a nil check. The field is
+// then classified as FieldRiskImplicitRequirement even when the schema marks
+// it optional.
+func (p *ProtoFieldAnalyzer) MarkImplicitRequirement(msg *types.Named, field, site string) {
+	p.markRequirement(messageKey(msg), field, site)
+}
+
+// markRequirement implements MarkImplicitRequirement for the message whose
+// messageKey is key, which may have been mined in another package.
+func (p *ProtoFieldAnalyzer) markRequirement(key, field, site string) {
+	if p.consumers == nil {
+		p.consumers = make(map[string]map[string]string)
+	}
+	fields := p.consumers[key]
+	if fields == nil {
+		fields = make(map[string]string)
+		p.consumers[key] = fields
+	}
+	if _, ok := fields[field]; ok {
+		return
+	}
+	fields[field] = site
+	for named := range p.cache {
+		if messageKey(named) == key {
+			delete(p.cache, named)
+		}
+	}
+}
+
+// messageKey identifies msg across packages and type-checker runs by its
+// package path and name.
+func messageKey(msg *types.Named) string {
+	if msg.Obj().Pkg() == nil {
+		return msg.Obj().Name()
+	}
+	return msg.Obj().Pkg().Path() + "." + msg.Obj().Name()
+}
+
+// protoFullName returns the proto full name of named, or "" when no
+// descriptor set is loaded or the type is not described by it.
+func (p *ProtoFieldAnalyzer) protoFullName(named *types.Named) string {
+	if desc := p.descriptors.Lookup(named); desc != nil {
+		return desc.FullName
+	}
+	return ""
+}
+
+// TakeMismatches returns the policy mismatches recorded since the last call.
+func (p *ProtoFieldAnalyzer) TakeMismatches() []PolicyMismatch {
+	out := p.mismatches
+	p.mismatches = nil
+	return out
+}
+
 // AnalyzeMessage extracts metadata for a proto-generated message type.
 func (p *ProtoFieldAnalyzer) AnalyzeMessage(named *types.Named) *ProtoMessageInfo {
 	if named == nil {
@@ -37,6 +104,11 @@ func (p *ProtoFieldAnalyzer) AnalyzeMessage(named *types.Named) *ProtoMessageInf
 		return info
 	}
 
+	desc := p.descriptors.Lookup(named)
+	if desc != nil {
+		info.FullName = desc.FullName
+	}
+
 	for i := 0; i < structType.NumFields(); i++ {
 		field := structType.Field(i)
 		if !field.Exported() {
@@ -45,6 +117,16 @@ func (p *ProtoFieldAnalyzer) AnalyzeMessage(named *types.Named) *ProtoMessageInf
 
 		tag := structType.Tag(i)
 		meta := p.classifyField(named, field, tag)
+		if desc != nil {
+			p.applyDescriptor(&meta, field, desc)
+		}
+		if site, ok := p.consumers[messageKey(named)][meta.Name]; ok {
+			meta.ConsumerSite = site
+			if meta.Risk == FieldRiskSafe && meta.IsPointer && meta.IsProtoMessage {
+				meta.Risk = FieldRiskImplicitRequirement
+				meta.RiskReason = "dereferenced by a consumer without nil check"
+			}
+		}
 		info.Fields = append(info.Fields, meta)
 		info.FieldByID[i] = meta
 		if meta.Risk != FieldRiskSafe {
@@ -78,11 +160,24 @@ func (p *ProtoFieldAnalyzer) classifyField(parent *types.Named, field *types.Var
 	isOptional := hasOneOfTag(tag)
 
 	risk := FieldRiskSafe
+	var reason string
 	switch {
 	case isRepeated && elementIsProtoMessage(fieldType):
 		risk = FieldRiskRepeatedMessagePointer
+		reason = "repeated sub-message; elements must not be nil"
 	case isPointer && isProtoMessage && !isOptional:
 		risk = FieldRiskMessagePointer
+		reason = "sub-message pointer without oneof tag"
+	case isPointer && isProtoMessage:
+		reason = "oneof sub-message; nil is allowed"
+	case isRepeated:
+		reason = "repeated scalar; nil is an empty list"
+	case isMap:
+		reason = "map; nil is an empty map"
+	case isPointer:
+		reason = "pointer to non-message type"
+	default:
+		reason = "scalar; zero value is safe"
 	}
 
 	return FieldInfo{
@@ -98,7 +193,81 @@ func (p *ProtoFieldAnalyzer) classifyField(parent *types.Named, field *types.Var
 		IsProtoMessage:  isProtoMessage,
 		MessageTypeName: messageTypeName(fieldType),
 		Risk:            risk,
+		RiskReason:      reason,
+	}
+}
+
+// applyDescriptor overrides the heuristic classification in meta with the
+// policy described by desc, recording a mismatch when the two disagree.
+func (p *ProtoFieldAnalyzer) applyDescriptor(meta *FieldInfo, field *types.Var, desc *MessageDescriptor) {
+	number, ok := protoFieldNumber(meta.Tag)
+	if !ok {
+		return
+	}
+	fd := desc.Field(number)
+	if fd == nil {
+		return
 	}
+
+	risk, optional := desc.classifyField(fd)
+	if risk != meta.Risk {
+		p.mismatches = append(p.mismatches, PolicyMismatch{
+			Field:          field,
+			Message:        meta.Parent,
+			FieldName:      meta.Name,
+			ProtoFullName:  desc.FullName + "." + fd.GetName(),
+			HeuristicRisk:  meta.Risk,
+			DescriptorRisk: risk,
+		})
+	}
+	meta.Risk = risk
+	meta.IsOptional = optional
+
+	var reason string
+	switch {
+	case risk == FieldRiskRepeatedMessagePointer:
+		reason = "repeated message field"
+	case risk == FieldRiskMessagePointer:
+		reason = "singular message field outside any oneof"
+	case optional:
+		reason = "optional or oneof field"
+	case fd.GetType() == descriptorpb.FieldDescriptorProto_TYPE_MESSAGE:
+		reason = "map field"
+	default:
+		reason = "scalar field"
+	}
+	meta.RiskReason = "descriptor " + desc.FullName + "." + fd.GetName() + ": " + reason
+}
+
+// protoGetterField reports whether method is a generated getter such as
+//
+//	func (x *User) GetProfile() *Profile
+//
+// and, if so, returns the message type and the field it reads.
+func protoGetterField(method *types.Func) (*types.Named, *types.Var) {
+	if method == nil || !strings.HasPrefix(method.Name(), "Get") {
+		return nil, nil
+	}
+	sig, ok := method.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil || sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return nil, nil
+	}
+	msg := receiverNamedType(sig.Recv().Type())
+	if msg == nil || !implementsProtoMessage(msg) {
+		return nil, nil
+	}
+	st, ok := msg.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil
+	}
+	name := strings.TrimPrefix(method.Name(), "Get")
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if field.Name() == name && types.Identical(field.Type(), sig.Results().At(0).Type()) {
+			return msg, field
+		}
+	}
+	return nil, nil
 }
 
 func isPointer(t types.Type) bool {
@@ -169,11 +338,7 @@ func hasOneOfTag(tag string) bool {
 }
 
 func tagHasFlag(tag, key, part string) bool {
-	if tag == "" {
-		return false
-	}
-	reflectTag := reflect.StructTag(tag)
-	value := reflectTag.Get(key)
+	value := reflectTagValue(tag, key)
 	if value == "" {
 		return false
 	}
@@ -187,3 +352,10 @@ func tagHasFlag(tag, key, part string) bool {
 	}
 	return false
 }
+
+func reflectTagValue(tag, key string) string {
+	if tag == "" {
+		return ""
+	}
+	return reflect.StructTag(tag).Get(key)
+}
@@ -0,0 +1,170 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// SchemaNode is the dump form of a ProtoMessageInfo tree, as printed by the
+// -dump-schema mode.
+type SchemaNode struct {
+	Type     string        `json:"type"`
+	FullName string        `json:"fullName,omitempty"`
+	Fields   []SchemaField `json:"fields,omitempty"`
+	// Recursive marks a message that is already being expanded further up
+	// the tree; its fields are omitted.
+	Recursive bool `json:"recursive,omitempty"`
+}
+
+// SchemaField describes one field of a SchemaNode. Message holds the
+// expanded sub-message for message-typed fields (including repeated and map
+// values).
+type SchemaField struct {
+	Name       string      `json:"name"`
+	GoType     string      `json:"goType"`
+	Tag        string      `json:"tag,omitempty"`
+	IsOptional bool        `json:"isOptional"`
+	IsRepeated bool        `json:"isRepeated"`
+	IsMap      bool        `json:"isMap"`
+	Risk       string      `json:"risk"`
+	Reason     string      `json:"reason"`
+	Consumer   string      `json:"consumer,omitempty"`
+	Message    *SchemaNode `json:"message,omitempty"`
+}
+
+// BuildSchemaTree expands the classification of named and all sub-messages
+// reachable from it.
+func BuildSchemaTree(p *ProtoFieldAnalyzer, named *types.Named) *SchemaNode {
+	return buildSchemaNode(p, named, make(map[*types.Named]bool))
+}
+
+func buildSchemaNode(p *ProtoFieldAnalyzer, named *types.Named, onPath map[*types.Named]bool) *SchemaNode {
+	info := p.AnalyzeMessage(named)
+	node := &SchemaNode{
+		Type:     qualifiedTypeName(named),
+		FullName: info.FullName,
+	}
+	if onPath[named] {
+		node.Recursive = true
+		return node
+	}
+	onPath[named] = true
+	defer delete(onPath, named)
+
+	for _, fi := range info.Fields {
+		field := SchemaField{
+			Name:       fi.Name,
+			GoType:     types.TypeString(fi.Type, packageNameQualifier),
+			Tag:        fi.Tag,
+			IsOptional: fi.IsOptional,
+			IsRepeated: fi.IsRepeated,
+			IsMap:      fi.IsMap,
+			Risk:       fi.Risk.String(),
+			Reason:     fi.RiskReason,
+		}
+		field.Consumer = fi.ConsumerSite
+		if sub := messageElemType(fi.Type); sub != nil {
+			field.Message = buildSchemaNode(p, sub, onPath)
+		}
+		node.Fields = append(node.Fields, field)
+	}
+	return node
+}
+
+// WriteSchema renders node to w in the given format ("text" or "json").
+func WriteSchema(w io.Writer, node *SchemaNode, format string) error {
+	switch format {
+	case "", "text":
+		var b strings.Builder
+		writeSchemaText(&b, node, 0)
+		_, err := io.WriteString(w, b.String())
+		return err
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(node)
+	}
+	return fmt.Errorf("unknown schema dump format %q", format)
+}
+
+func writeSchemaText(b *strings.Builder, node *SchemaNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	b.WriteString(indent + node.Type)
+	if node.FullName != "" {
+		b.WriteString(" [" + node.FullName + "]")
+	}
+	if node.Recursive {
+		b.WriteString(" (recursive, see above)\n")
+		return
+	}
+	b.WriteString("\n")
+
+	for _, f := range node.Fields {
+		fmt.Fprintf(b, "%s  %s %s", indent, f.Name, f.GoType)
+		if f.Tag != "" {
+			fmt.Fprintf(b, " `%s`", f.Tag)
+		}
+		fmt.Fprintf(b, " optional=%t repeated=%t map=%t risk=%q reason=%q",
+			f.IsOptional, f.IsRepeated, f.IsMap, f.Risk, f.Reason)
+		if f.Consumer != "" {
+			fmt.Fprintf(b, " consumer=%s", f.Consumer)
+		}
+		b.WriteString("\n")
+		if f.Message != nil {
+			writeSchemaText(b, f.Message, depth+2)
+		}
+	}
+}
+
+// messageElemType returns the proto message type held by t, looking through
+// pointers, slices and map values.
+func messageElemType(t types.Type) *types.Named {
+	switch tt := t.(type) {
+	case *types.Slice:
+		t = tt.Elem()
+	case *types.Map:
+		t = tt.Elem()
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || !implementsProtoMessage(named) {
+		return nil
+	}
+	return named
+}
+
+// lookupSchemaType finds the message named name in pkg or its direct imports.
+// name may be a bare Go type name, an import-path-qualified name
+// ("example.com/pb.User") or a proto full name when descriptors are loaded.
+func lookupSchemaType(p *ProtoFieldAnalyzer, pkg *types.Package, name string) []*types.Named {
+	var found []*types.Named
+	for _, scopePkg := range append([]*types.Package{pkg}, pkg.Imports()...) {
+		for _, objName := range scopePkg.Scope().Names() {
+			tn, ok := scopePkg.Scope().Lookup(objName).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			named, ok := tn.Type().(*types.Named)
+			if !ok || !implementsProtoMessage(named) {
+				continue
+			}
+			if objName == name || scopePkg.Path()+"."+objName == name || p.protoFullName(named) == name {
+				found = append(found, named)
+			}
+		}
+	}
+	return found
+}
+
+func qualifiedTypeName(named *types.Named) string {
+	return types.TypeString(named, packageNameQualifier)
+}
+
+func packageNameQualifier(pkg *types.Package) string {
+	return pkg.Name()
+}
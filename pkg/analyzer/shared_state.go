@@ -0,0 +1,231 @@
+package analyzer
+
+import (
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// fieldKey identifies a field of a named struct type.
+type fieldKey struct {
+	owner *types.Named
+	field int
+}
+
+// globalStatus proves the package variable g non-nil when every store into
+// it in Funcs is non-nil and the package initializer always assigns it.
+// Variables whose address is used other than to load or store them,
+// exported variables, which other packages may reassign, and variables of
+// other packages are FieldLoadDefault.
+func (a *NilFlowAnalyzer) globalStatus(g *ssa.Global) NilStatus {
+	if s, ok := a.globals[g]; ok {
+		return s
+	}
+	// Break cycles between variables initialized from each other.
+	a.globals[g] = a.FieldLoadDefault
+
+	status := a.computeGlobalStatus(g)
+	a.globals[g] = status
+	return status
+}
+
+func (a *NilFlowAnalyzer) computeGlobalStatus(g *ssa.Global) NilStatus {
+	if !a.ownsPackage(g.Pkg) || g.Object().Exported() {
+		return a.FieldLoadDefault
+	}
+	status := nilStatusBottom
+	initialized := false
+	for _, fn := range a.Funcs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				store, isStore := instr.(*ssa.Store)
+				if isStore && store.Addr == g {
+					status = joinNilStatus(status, a.factAt(store.Val, store).resolve())
+					initialized = initialized || (isInitFunc(fn) && runsOnInit(b))
+					continue
+				}
+				if load, ok := instr.(*ssa.UnOp); ok && load.X == g {
+					continue
+				}
+				if usesValue(instr, g) {
+					return a.FieldLoadDefault
+				}
+			}
+		}
+	}
+	if !initialized {
+		status = joinNilStatus(status, zeroFact(g.Type().(*types.Pointer).Elem()).Status)
+	}
+	return status
+}
+
+// fieldStatus proves field number field of owner non-nil when owner is an
+// unexported non-message struct type of the analyzed package, is
+// constructed in Funcs, and the field is non-nil whenever a new value of
+// owner is first used other than to set its fields, and at every store into
+// it elsewhere. Fields whose address is passed on or kept are
+// FieldLoadDefault. Other packages may construct values of exported types, and
+// assign exported fields, out of sight, so those are FieldLoadDefault.
+func (a *NilFlowAnalyzer) fieldStatus(owner *types.Named, field int) NilStatus {
+	key := fieldKey{owner, field}
+	if s, ok := a.fields[key]; ok {
+		return s
+	}
+	a.fields[key] = a.FieldLoadDefault
+
+	status := a.computeFieldStatus(owner, field)
+	a.fields[key] = status
+	return status
+}
+
+func (a *NilFlowAnalyzer) computeFieldStatus(owner *types.Named, field int) NilStatus {
+	if !a.ownsPackageOf(owner.Obj()) || owner.Obj().Exported() || implementsProtoMessage(owner) {
+		return a.FieldLoadDefault
+	}
+	st, ok := owner.Underlying().(*types.Struct)
+	if !ok || st.Field(field).Exported() {
+		return a.FieldLoadDefault
+	}
+
+	status := nilStatusBottom
+	constructed := false
+	for _, fn := range a.Funcs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Store:
+					if fa, ok := instr.Addr.(*ssa.FieldAddr); ok && fa.Field == field && pointsTo(fa.X.Type(), owner) {
+						status = joinNilStatus(status, a.factAt(instr.Val, instr).resolve())
+					} else if _, isAlloc := instr.Addr.(*ssa.Alloc); !isAlloc && pointsTo(instr.Addr.Type(), owner) {
+						// A whole value overwritten in place; local variables
+						// are covered as allocations below.
+						status = joinNilStatus(status, a.FieldLoadDefault)
+					}
+				case *ssa.FieldAddr:
+					if instr.Field == field && pointsTo(instr.X.Type(), owner) && !onlyLoadedOrStored(instr) {
+						// The address escapes, e.g. into a helper taking
+						// **T, which may store into it out of sight.
+						return a.FieldLoadDefault
+					}
+				case *ssa.Alloc:
+					if !pointsTo(instr.Type(), owner) {
+						continue
+					}
+					constructed = true
+					for _, use := range publishingUses(instr) {
+						status = joinNilStatus(status, a.trackedFieldFact(instr, []int{field}, use).resolve())
+					}
+				}
+			}
+		}
+	}
+	if !constructed {
+		return a.FieldLoadDefault
+	}
+	return status
+}
+
+// onlyLoadedOrStored reports whether the address fa is only used to load
+// from or store into the field.
+func onlyLoadedOrStored(fa *ssa.FieldAddr) bool {
+	for _, ref := range *fa.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.Store:
+			if ref.Addr != fa || ref.Val == fa {
+				return false
+			}
+		case *ssa.UnOp:
+			if ref.Op != token.MUL {
+				return false
+			}
+		case *ssa.DebugRef:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// publishingUses returns the uses of alloc that make the new value visible
+// to other code, i.e. all uses other than direct field accesses.
+func publishingUses(alloc *ssa.Alloc) []ssa.Instruction {
+	var uses []ssa.Instruction
+	for _, ref := range *alloc.Referrers() {
+		switch ref.(type) {
+		case *ssa.FieldAddr, *ssa.DebugRef:
+		default:
+			uses = append(uses, ref)
+		}
+	}
+	return uses
+}
+
+// ownsPackage reports whether pkg is the package whose functions are Funcs.
+func (a *NilFlowAnalyzer) ownsPackage(pkg *ssa.Package) bool {
+	for _, fn := range a.Funcs {
+		if fn.Pkg == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *NilFlowAnalyzer) ownsPackageOf(obj types.Object) bool {
+	for _, fn := range a.Funcs {
+		if fn.Pkg != nil && fn.Pkg.Pkg == obj.Pkg() {
+			return true
+		}
+	}
+	return false
+}
+
+// isInitFunc reports whether fn is the package initializer or an init
+// function, which run unconditionally before any handler.
+func isInitFunc(fn *ssa.Function) bool {
+	if fn.Parent() != nil || fn.Signature.Recv() != nil {
+		return false
+	}
+	return fn.Name() == "init" || strings.HasPrefix(fn.Name(), "init#")
+}
+
+// runsOnInit reports whether b runs whenever the init function containing
+// it does. The package initializer skips its body once its guard is set,
+// so the edge from the guard check is ignored there.
+func runsOnInit(b *ssa.BasicBlock) bool {
+	fn := b.Parent()
+	if fn.Synthetic == "" {
+		return dominatesReturns(b)
+	}
+	for _, other := range fn.Blocks {
+		if len(other.Instrs) == 0 || other == b {
+			continue
+		}
+		if _, ok := other.Instrs[len(other.Instrs)-1].(*ssa.Return); !ok {
+			continue
+		}
+		for _, pred := range other.Preds {
+			if pred != fn.Blocks[0] && !b.Dominates(pred) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// usesValue reports whether v is an operand of instr.
+func usesValue(instr ssa.Instruction, v ssa.Value) bool {
+	for _, op := range instr.Operands(nil) {
+		if *op == v {
+			return true
+		}
+	}
+	return false
+}
+
+// pointsTo reports whether t is a pointer to named.
+func pointsTo(t types.Type, named *types.Named) bool {
+	ptr, ok := t.Underlying().(*types.Pointer)
+	return ok && types.Identical(ptr.Elem(), named)
+}
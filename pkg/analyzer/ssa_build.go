@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/build"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+)
+
+// Declaring facts makes the driver run the analyzer, and everything it
+// requires, on every dependency of the analyzed packages, the standard
+// library included. SSA is therefore built here rather than by the shared
+// buildssa pass, so that packages outside the analyzed module cost nothing;
+// calls that never return are pruned by the analyzer itself.
+
+// inAnalyzedModule reports whether the package of pass belongs to the module
+// being analyzed: never for packages in GOROOT, which drivers may give an
+// empty Module, the main module in module mode, and any other package in
+// GOPATH mode.
+func inAnalyzedModule(pass *analysis.Pass) bool {
+	if len(pass.Files) == 0 {
+		return false
+	}
+	goroot := filepath.Join(build.Default.GOROOT, "src") + string(filepath.Separator)
+	if strings.HasPrefix(pass.Fset.File(pass.Files[0].Pos()).Name(), goroot) {
+		return false
+	}
+	return pass.Module == nil || pass.Module.Path == "" || pass.Module.Version == ""
+}
+
+// buildSSA builds the SSA form of the package of pass the way buildssa does,
+// returning the package and its source functions, literals included, in
+// source order.
+func buildSSA(pass *analysis.Pass) (*ssa.Package, []*ssa.Function) {
+	prog := ssa.NewProgram(pass.Fset, 0)
+	for _, p := range pass.Pkg.Imports() {
+		prog.CreatePackage(p, nil, nil, true)
+	}
+	pkg := prog.CreatePackage(pass.Pkg, pass.Files, pass.TypesInfo, false)
+	pkg.Build()
+
+	var funcs []*ssa.Function
+	var addAnons func(fn *ssa.Function)
+	addAnons = func(fn *ssa.Function) {
+		funcs = append(funcs, fn)
+		for _, anon := range fn.AnonFuncs {
+			addAnons(anon)
+		}
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok {
+				if fn := prog.FuncValue(pass.TypesInfo.Defs[decl.Name].(*types.Func)); fn != nil {
+					addAnons(fn)
+				}
+			}
+		}
+	}
+	return pkg, funcs
+}
@@ -0,0 +1,218 @@
+package analyzer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FuncSummary describes the nilness of a function's first result in terms
+// of its parameters: the result is at least Result, and additionally
+// inherits the nilness of every argument listed in DependsOn. Parameter
+// indices follow ssa.Function.Params, so a method's receiver is param 0;
+// the free variables of a closure are numbered after its parameters.
+//
+// Examples:
+//
+//	func identity(p *P) *P { return p }           // Result: Bottom, DependsOn: [0]
+//	func orDefault(p *P) *P { ...; return p }     // Result: NotNil, DependsOn: [0]
+//	func newP() *P { return &P{} }                // Result: NotNil
+//
+// For functions whose last result is an error, OnSuccess summarizes the
+// first result over the return sites where the error may be nil, so that
+// callers checking err can rely on it. Sets lists the fields of messages
+// passed as parameters that the function assigns. NoReturn records that
+// the function never returns normally, e.g. because it always panics.
+type FuncSummary struct {
+	Result    NilStatus
+	DependsOn []int
+	Source    SummarySource
+	OnSuccess *FuncSummary
+	Sets      []FieldSet
+	NoReturn  bool
+}
+
+// FieldSet records that a function assigns field number Field, named Name,
+// of the proto message its parameter Param points to, either directly or
+// through a callee. Field is PointeeField when the parameter is a pointer
+// to a message pointer, e.g. fill(pp **pb.Profile), and the function assigns
+// *pp. Value summarizes the values assigned, and Always records that the
+// field is assigned on every path to a normal return rather than only on
+// some.
+type FieldSet struct {
+	Param  int
+	Field  int
+	Name   string
+	Value  *FuncSummary
+	Always bool
+}
+
+// PointeeField is the Field of a FieldSet assigning the variable its
+// parameter points to.
+const PointeeField = -1
+
+// SummarySource records how a FuncSummary was obtained, distinguishing
+// proven results from assumptions.
+type SummarySource int
+
+const (
+	// SummaryFromBody: derived from the function's SSA body.
+	SummaryFromBody SummarySource = iota
+	// SummaryNotAnalyzed: no body was available; Result is the configured
+	// default for bodiless functions, not a proof.
+	SummaryNotAnalyzed
+	// SummaryFromModel: taken from the built-in table of known functions.
+	SummaryFromModel
+	// SummaryFromContract: declared by a //grpcnil: directive.
+	SummaryFromContract
+)
+
+// Instantiate computes the result status for a call whose arguments have
+// the given statuses. Missing arguments are treated as Unknown.
+func (s *FuncSummary) Instantiate(args []NilStatus) NilStatus {
+	return s.instantiate(func(i int) NilStatus {
+		if i < len(args) {
+			return args[i]
+		}
+		return NilStatusUnknown
+	})
+}
+
+func (s *FuncSummary) instantiate(arg func(int) NilStatus) NilStatus {
+	status := s.Result
+	for _, i := range s.DependsOn {
+		status = joinNilStatus(status, arg(i))
+	}
+	if status == nilStatusBottom {
+		// No return site produced a value: the function never returns
+		// normally, so no nil can flow out of it.
+		return NilStatusNotNil
+	}
+	return status
+}
+
+// fact converts s back into the parameter-relative fact it was built from.
+func (s *FuncSummary) fact() nilFact {
+	f := nilFact{Status: s.Result}
+	for _, i := range s.DependsOn {
+		f.Params |= 1 << i
+	}
+	return f
+}
+
+// successFact returns the fact of the first result on success, or bottom
+// when s has no error result.
+func (s *FuncSummary) successFact() nilFact {
+	if s.OnSuccess == nil {
+		return nilFact{Status: nilStatusBottom}
+	}
+	return s.OnSuccess.fact()
+}
+
+// String renders the summary in words, e.g. "returns param 0" or
+// "non-nil if param 1 non-nil". Assumed summaries are marked as such, and
+// a stronger guarantee on success and the assigned fields are appended,
+// e.g. "MaybeNil; NotNil if err == nil; sets param 0 Profile: NotNil" or
+// "may set *param 1: MaybeNil".
+func (s *FuncSummary) String() string {
+	if s.NoReturn {
+		return "never returns"
+	}
+	out := s.describe()
+	if s.OnSuccess != nil {
+		if success := s.OnSuccess.describe(); success != out {
+			out += "; " + success + " if err == nil"
+		}
+	}
+	for _, set := range s.Sets {
+		value := strings.TrimPrefix(set.Value.describe(), "returns ")
+		verb := "; sets "
+		if !set.Always {
+			verb = "; may set "
+		}
+		if set.Field == PointeeField {
+			out += verb + "*param " + strconv.Itoa(set.Param) + ": " + value
+			continue
+		}
+		out += verb + "param " + strconv.Itoa(set.Param) + " " + set.Name + ": " + value
+	}
+	switch s.Source {
+	case SummaryNotAnalyzed:
+		out += " (not analyzed)"
+	case SummaryFromModel:
+		out += " (model)"
+	case SummaryFromContract:
+		out += " (contract)"
+	}
+	return out
+}
+
+func (s *FuncSummary) describe() string {
+	if len(s.DependsOn) == 0 {
+		if s.Result == nilStatusBottom {
+			return NilStatusNotNil.String()
+		}
+		return s.Result.String()
+	}
+	params := make([]string, len(s.DependsOn))
+	for i, p := range s.DependsOn {
+		params[i] = strconv.Itoa(p)
+	}
+	switch s.Result {
+	case nilStatusBottom:
+		return "returns param " + strings.Join(params, " or param ")
+	case NilStatusNotNil:
+		if len(params) == 1 {
+			return "non-nil if param " + params[0] + " non-nil"
+		}
+		return "non-nil if params " + strings.Join(params, " and ") + " non-nil"
+	}
+	return s.Result.String()
+}
+
+// paramSet is a bitset of parameter indices.
+type paramSet uint64
+
+// maxTrackedParams bounds the parameter indices a paramSet can hold; values
+// depending on later parameters are treated as Unknown.
+const maxTrackedParams = 64
+
+func (p paramSet) indices() []int {
+	var out []int
+	for i := 0; i < maxTrackedParams; i++ {
+		if p&(1<<i) != 0 {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// nilFact is the abstract nilness of an SSA value, expressed relative to the
+// parameters of its enclosing function: the value is at least Status and is
+// additionally nil whenever a parameter in Params is.
+type nilFact struct {
+	Status NilStatus
+	Params paramSet
+}
+
+func (f nilFact) join(g nilFact) nilFact {
+	return nilFact{Status: joinNilStatus(f.Status, g.Status), Params: f.Params | g.Params}
+}
+
+// summary converts a function's joined return fact into a FuncSummary.
+func (f nilFact) summary() *FuncSummary {
+	return &FuncSummary{Result: f.Status, DependsOn: f.Params.indices()}
+}
+
+// resolve instantiates f in a context where every parameter is Unknown.
+// A bottom fact (e.g. the result of a call that never returns) carries no
+// nil and resolves to NotNil.
+func (f nilFact) resolve() NilStatus {
+	status := f.Status
+	if f.Params != 0 {
+		status = joinNilStatus(status, NilStatusUnknown)
+	}
+	if status == nilStatusBottom {
+		return NilStatusNotNil
+	}
+	return status
+}
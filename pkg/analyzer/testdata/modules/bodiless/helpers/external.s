@@ -0,0 +1 @@
+// External is provided by the platform at link time.
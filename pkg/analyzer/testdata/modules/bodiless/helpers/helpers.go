@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"time"
+
+	"example.com/bodiless/pb"
+)
+
+// NewProfile always returns a fresh profile.
+func NewProfile() *pb.Profile {
+	return &pb.Profile{}
+}
+
+// MaybeProfile returns nil on odd seconds.
+func MaybeProfile() *pb.Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &pb.Profile{}
+	}
+	return nil
+}
+
+// Builder produces profiles.
+type Builder struct{}
+
+// Build always returns a fresh profile.
+func (b *Builder) Build() *pb.Profile {
+	return NewProfile()
+}
+
+// External is implemented in assembly, so no package has a body for it.
+func External() *pb.Profile
@@ -0,0 +1,43 @@
+package svc
+
+import (
+	"context"
+
+	"example.com/bodiless/helpers"
+	"example.com/bodiless/pb"
+)
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct {
+	builder *helpers.Builder
+}
+
+// GetUserFresh uses a helper from another package of the module whose
+// summary, exported while analyzing its package, proves it non-nil.
+func (s *Service) GetUserFresh(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = helpers.NewProfile()
+	return resp, nil
+}
+
+// GetUserBuilt calls a method declared in another package of the module.
+func (s *Service) GetUserBuilt(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = s.builder.Build()
+	return resp, nil
+}
+
+// GetUserMaybe must be flagged: the helper in another package can return nil.
+func (s *Service) GetUserMaybe(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = helpers.MaybeProfile() // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserExternal calls a helper without any body, whose result is not
+// analyzed and must not be assumed non-nil.
+func (s *Service) GetUserExternal(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = helpers.External() // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+
+	"example.com/stdimports/pb"
+)
+
+// ProfileFromEnv returns nil unless PROFILE is set.
+func ProfileFromEnv() *pb.Profile { // want ProfileFromEnv:"summary MaybeNil"
+	if os.Getenv("PROFILE") == "" {
+		fmt.Fprintln(os.Stderr, "no profile")
+		return nil
+	}
+	return &pb.Profile{}
+}
+
+// MustProfile exits instead of returning nil.
+func MustProfile() *pb.Profile { // want MustProfile:"summary NotNil"
+	p := ProfileFromEnv()
+	if p == nil {
+		fmt.Println("profile required")
+		os.Exit(1)
+	}
+	return p
+}
@@ -0,0 +1,21 @@
+package pb
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
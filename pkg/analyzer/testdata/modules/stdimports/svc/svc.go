@@ -0,0 +1,31 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"example.com/stdimports/helpers"
+	"example.com/stdimports/pb"
+)
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserEnv must be flagged: the helper returns nil without PROFILE.
+func (s *Service) GetUserEnv(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	fmt.Fprintf(os.Stderr, "GetUserEnv %v\n", req)
+	resp := &pb.GetUserResponse{}
+	resp.Profile = helpers.ProfileFromEnv() // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserMust is fine: the helper exits instead of returning nil.
+func (s *Service) GetUserMust(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if _, err := os.Stat("/nonexistent"); err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	resp := &pb.GetUserResponse{}
+	resp.Profile = helpers.MustProfile()
+	return resp, nil
+}
@@ -0,0 +1,146 @@
+package aliasflow
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with required sub-messages.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+	Backup  *Profile `protobuf:"bytes,2,opt,name=backup,proto3"`
+	User    *User    `protobuf:"bytes,3,opt,name=user,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// User is a nested sub-message with a required sub-message of its own.
+type User struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks User as a proto message.
+func (*User) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+func fill(pp **Profile) {
+	*pp = &Profile{}
+}
+
+func fillMaybe(pp **Profile) {
+	*pp = maybe()
+}
+
+func fillSometimes(pp **Profile, ok bool) {
+	if ok {
+		*pp = &Profile{}
+	}
+}
+
+func fillUser(u *User) {
+	fill(&u.Profile)
+}
+
+// FillProfile assigns the profile its parameter points to.
+func FillProfile(pp **Profile) { // want FillProfile:"summary NotNil; sets \\*param 0: NotNil"
+	fill(pp)
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserPhiAlias stores through an address that may be either field.
+func (s *Service) GetUserPhiAlias(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	p := &resp.Profile
+	if ctx.Err() != nil {
+		p = &resp.Backup
+	}
+	*p = maybe()     // want "potential nil field in gRPC response GetUserResponse.Profile" "potential nil field in gRPC response GetUserResponse.Backup"
+	return resp, nil // want "implicit nil field in gRPC response GetUserResponse.Profile" "implicit nil field in gRPC response GetUserResponse.Backup"
+}
+
+// GetUserPhiAliasFresh stores a fresh profile through an address that may
+// be either field, so one of them is always left nil.
+func (s *Service) GetUserPhiAliasFresh(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	p := &resp.Profile
+	if ctx.Err() != nil {
+		p = &resp.Backup
+	}
+	*p = &Profile{}
+	return resp, nil // want "implicit nil field in gRPC response GetUserResponse.Profile" "implicit nil field in gRPC response GetUserResponse.Backup"
+}
+
+// GetUserCapturedAlias stores through an address captured by a closure.
+func (s *Service) GetUserCapturedAlias(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	p := &resp.Backup
+	func() {
+		*p = &Profile{}
+	}()
+	resp.Profile = &Profile{}
+	return resp, nil
+}
+
+// GetUserHelpers fills fields through helpers taking their addresses.
+func (s *Service) GetUserHelpers(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	fill(&resp.Profile)
+	FillProfile(&resp.Backup)
+	u := &User{}
+	fillUser(u)
+	resp.User = u
+	return resp, nil
+}
+
+// GetUserMaybeHelper fills a field through a helper that may store nil.
+func (s *Service) GetUserMaybeHelper(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{Backup: &Profile{}, User: &User{Profile: &Profile{}}}
+	fillMaybe(&resp.Profile) // want "potential nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserMaybeHelper\\); set by fillMaybe"
+	return resp, nil
+}
+
+// GetUserSometimesHelper fills a field through a helper that may skip it.
+func (s *Service) GetUserSometimesHelper(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{Backup: &Profile{}, User: &User{Profile: &Profile{}}}
+	fillSometimes(&resp.Profile, ctx.Err() == nil)
+	return resp, nil // want "implicit nil field in gRPC response GetUserResponse.Profile"
+}
+
+// GetUserNestedAlias fills a sub-message field through a local alias.
+func (s *Service) GetUserNestedAlias(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{Profile: &Profile{}, Backup: &Profile{}}
+	u := &User{}
+	pp := &u.Profile
+	fill(pp)
+	resp.User = u
+	return resp, nil
+}
+
+// GetUserUnfilled never fills the user's profile.
+func (s *Service) GetUserUnfilled(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{Profile: &Profile{}, Backup: &Profile{}}
+	u := &User{}
+	resp.User = u // want "implicit nil field in gRPC response GetUserResponse.User.Profile"
+	return resp, nil
+}
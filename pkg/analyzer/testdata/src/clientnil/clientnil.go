@@ -0,0 +1,109 @@
+package clientnil // want package:"requires GetUserResponse.User at clientnil.go:92, User.Profile at clientnil.go:92, GetUserResponse.Avatar at clientnil.go:93, GetUserResponse.Cover at clientnil.go:97, GetUserResponse.Header at clientnil.go:104, GetUserResponse.Footer at clientnil.go:106"
+
+import (
+	"context"
+	"strings"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse has schema-optional sub-messages. Avatar is dereferenced
+// directly by the client below and therefore implicitly required; Banner is
+// always nil-checked first and stays optional; Cover is only checked after
+// it was dereferenced, Header is dereferenced after the checked branch
+// rejoins, and Footer only where it is nil, none of which count.
+type GetUserResponse struct {
+	User   *User  `protobuf:"bytes,1,opt,name=user,proto3"`
+	Avatar *Image `protobuf:"bytes,2,opt,name=avatar,proto3,oneof"`
+	Banner *Image `protobuf:"bytes,3,opt,name=banner,proto3,oneof"`
+	Cover  *Image `protobuf:"bytes,4,opt,name=cover,proto3,oneof"`
+	Header *Image `protobuf:"bytes,5,opt,name=header,proto3,oneof"`
+	Footer *Image `protobuf:"bytes,6,opt,name=footer,proto3,oneof"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// GetUser is a generated-style nil-safe getter.
+func (x *GetUserResponse) GetUser() *User { // want GetUser:"summary MaybeNil"
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+// User has a schema-optional Profile that the client reaches through a getter chain.
+type User struct {
+	Name    string   `protobuf:"bytes,1,opt,name=name,proto3"`
+	Profile *Profile `protobuf:"bytes,2,opt,name=profile,proto3,oneof"`
+}
+
+// ProtoMessage marks User as a proto message.
+func (*User) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3"`
+}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// Image is a nested sub-message type.
+type Image struct {
+	URL string `protobuf:"bytes,1,opt,name=url,proto3"`
+}
+
+// ProtoMessage marks Image as a proto message.
+func (*Image) ProtoMessage() {}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUser sets User but leaves the optional sub-messages unset. Avatar and
+// User.Profile, which the client dereferences, should be reported.
+func (s *Service) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.User = &User{} // want `implicit nil field in gRPC response GetUserResponse.User.Profile \(handler Service.GetUser\); dereferenced without nil check at clientnil.go:92`
+	return resp, nil    // want `implicit nil field in gRPC response GetUserResponse.Avatar \(handler Service.GetUser\); dereferenced without nil check at clientnil.go:93` `implicit nil field in gRPC response GetUserResponse.Cover \(handler Service.GetUser\); dereferenced without nil check at clientnil.go:97` `implicit nil field in gRPC response GetUserResponse.Header \(handler Service.GetUser\); dereferenced without nil check at clientnil.go:104` `implicit nil field in gRPC response GetUserResponse.Footer \(handler Service.GetUser\); dereferenced without nil check at clientnil.go:106`
+}
+
+// GetOwner returns a User without a Profile, which the client requires.
+func (s *Service) GetOwner(ctx context.Context, req *GetUserRequest) (*User, error) {
+	user := &User{}
+	user.Profile = maybeProfile() // want `potential nil field in gRPC response User.Profile \(handler Service.GetOwner\); dereferenced without nil check at clientnil.go:92`
+	return user, nil
+}
+
+func maybeProfile() *Profile {
+	if strings.Contains("a", "b") {
+		return &Profile{}
+	}
+	return nil
+}
+
+// render is client code consuming the response.
+func render(resp *GetUserResponse) string {
+	var b strings.Builder
+	b.WriteString(resp.GetUser().Profile.Name)
+	b.WriteString(resp.Avatar.URL)
+	if resp.Banner != nil {
+		b.WriteString(resp.Banner.URL)
+	}
+	b.WriteString(resp.Cover.URL)
+	if resp.Cover != nil {
+		b.WriteString(resp.Cover.URL)
+	}
+	if resp.Header != nil {
+		b.WriteString("header")
+	}
+	b.WriteString(resp.Header.URL)
+	if resp.Footer == nil {
+		b.WriteString(resp.Footer.URL)
+	}
+	return b.String()
+}
@@ -0,0 +1,13 @@
+package client // want package:"requires GetUserResponse.Profile at client.go:8"
+
+import "clientx/pb"
+
+// Describe is client code elsewhere in the module consuming the response.
+func Describe(resp *pb.GetUserResponse) string {
+	out := ""
+	out += resp.Profile.Name
+	if resp.Banner != nil {
+		out += resp.Banner.URL
+	}
+	return out
+}
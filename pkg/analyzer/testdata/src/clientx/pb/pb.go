@@ -0,0 +1,47 @@
+package pb // want package:"requires GetUserResponse.Avatar at pb.go:42"
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse has schema-optional sub-messages that the consumers in
+// this package and in package client dereference.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3,oneof"`
+	Avatar  *Image   `protobuf:"bytes,2,opt,name=avatar,proto3,oneof"`
+	Banner  *Image   `protobuf:"bytes,3,opt,name=banner,proto3,oneof"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3"`
+}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// Image is a nested sub-message type.
+type Image struct {
+	URL string `protobuf:"bytes,1,opt,name=url,proto3"`
+}
+
+// ProtoMessage marks Image as a proto message.
+func (*Image) ProtoMessage() {}
+
+// UserClient is a generated-style client wrapper.
+type UserClient struct{}
+
+// AvatarURL fetches a user and returns the URL of its avatar.
+func (c *UserClient) AvatarURL() string {
+	resp := c.fetch()
+	return resp.Avatar.URL
+}
+
+func (c *UserClient) fetch() *GetUserResponse {
+	return &GetUserResponse{}
+}
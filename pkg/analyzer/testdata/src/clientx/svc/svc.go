@@ -0,0 +1,20 @@
+package svc
+
+import (
+	"context"
+	"log"
+
+	"clientx/client"
+	"clientx/pb"
+)
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUser leaves every sub-message unset. Profile and Avatar, which the
+// consumers in other packages dereference, should be reported.
+func (s *Service) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	log.Print(client.Describe(resp))
+	return resp, nil // want `implicit nil field in gRPC response GetUserResponse.Profile \(handler Service.GetUser\); dereferenced without nil check at client.go:8` `implicit nil field in gRPC response GetUserResponse.Avatar \(handler Service.GetUser\); dereferenced without nil check at pb.go:42`
+}
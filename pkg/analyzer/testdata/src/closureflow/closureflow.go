@@ -0,0 +1,133 @@
+package closureflow
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+func run(f func()) {}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserBuilt builds the sub-message in a closure.
+func (s *Service) GetUserBuilt(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	build := func() *Profile {
+		return &Profile{}
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = build()
+	return resp, nil
+}
+
+// GetUserCaptured returns a checked captured value from a closure.
+func (s *Service) GetUserCaptured(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := maybe()
+	if p == nil {
+		p = &Profile{}
+	}
+	get := func() *Profile {
+		return p
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = get()
+	return resp, nil
+}
+
+// GetUserWrapped passes a value through a closure parameter.
+func (s *Service) GetUserWrapped(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	wrap := func(p *Profile) *Profile {
+		return p
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = wrap(&Profile{})
+	return resp, nil
+}
+
+// GetUserAssigned sets a captured variable in a closure before storing it.
+func (s *Service) GetUserAssigned(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	set := func() {
+		p = &Profile{}
+	}
+	set()
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserBuiltMaybe builds a sub-message that can be nil and must be
+// flagged.
+func (s *Service) GetUserBuiltMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	build := func() *Profile {
+		return maybe()
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = build() // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserAssignedMaybe sets the captured variable on one path only and
+// must be flagged.
+func (s *Service) GetUserAssignedMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	set := func() {
+		if time.Now().Unix()%2 == 0 {
+			p = &Profile{}
+		}
+	}
+	set()
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserNotCalled never calls the closure and must be flagged.
+func (s *Service) GetUserNotCalled(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	set := func() {
+		p = &Profile{}
+	}
+	_ = set
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserEscaped hands the closure to another function and must be flagged.
+func (s *Service) GetUserEscaped(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	run(func() {
+		p = &Profile{}
+	})
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
@@ -0,0 +1,142 @@
+package containerflow
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct {
+	Id string
+}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct {
+	profilesByID map[string]*Profile
+	profiles     []*Profile
+}
+
+// GetUserLookup returns a profile from a map that may lack the key.
+func (s *Service) GetUserLookup(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.profilesByID[req.Id] // want "potential nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserLookup\\); value from map lookup may be missing"
+	return resp, nil
+}
+
+// GetUserLocalLookup looks up a local map without checking the key.
+func (s *Service) GetUserLocalLookup(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	byID := map[string]*Profile{"a": {}, "b": {}}
+	resp := &GetUserResponse{}
+	resp.Profile = byID[req.Id] // want "value from map lookup may be missing"
+	return resp, nil
+}
+
+// GetUserCommaOk checks the key of a local map holding non-nil values.
+func (s *Service) GetUserCommaOk(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	byID := map[string]*Profile{"a": {}, "b": {}}
+	resp := &GetUserResponse{}
+	if p, ok := byID[req.Id]; ok {
+		resp.Profile = p
+	}
+	return resp, nil
+}
+
+// GetUserFallback replaces a missing value.
+func (s *Service) GetUserFallback(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	byID := make(map[string]*Profile)
+	byID[req.Id] = &Profile{}
+	p, ok := byID[req.Id]
+	if !ok {
+		p = &Profile{}
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserCommaOkNil checks the key of a map that may hold nil.
+func (s *Service) GetUserCommaOkNil(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	byID := map[string]*Profile{"a": maybe()}
+	resp := &GetUserResponse{}
+	if p, ok := byID[req.Id]; ok {
+		resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserCommaOkNil\\)$"
+	}
+	return resp, nil
+}
+
+// GetUserRange ranges over a local map holding non-nil values.
+func (s *Service) GetUserRange(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	byID := map[string]*Profile{"a": {}}
+	resp := &GetUserResponse{}
+	for _, p := range byID {
+		resp.Profile = p
+	}
+	return resp, nil
+}
+
+// GetUserLiteral indexes a slice literal of non-nil values.
+func (s *Service) GetUserLiteral(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	profiles := []*Profile{{}, {}}
+	resp := &GetUserResponse{}
+	resp.Profile = profiles[0]
+	return resp, nil
+}
+
+// GetUserLiteralNil indexes a slice literal holding a maybe-nil value.
+func (s *Service) GetUserLiteralNil(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	profiles := []*Profile{{}, maybe()}
+	resp := &GetUserResponse{}
+	resp.Profile = profiles[0] // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserMake indexes a slice whose second element is never set.
+func (s *Service) GetUserMake(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	profiles := make([]*Profile, 2)
+	profiles[0] = &Profile{}
+	resp := &GetUserResponse{}
+	resp.Profile = profiles[1] // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserAppend indexes a slice built by appending non-nil values.
+func (s *Service) GetUserAppend(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var profiles []*Profile
+	for i := 0; i < 3; i++ {
+		profiles = append(profiles, &Profile{})
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = profiles[0]
+	return resp, nil
+}
+
+// GetUserField indexes a slice of unknown origin.
+func (s *Service) GetUserField(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.profiles[0] // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
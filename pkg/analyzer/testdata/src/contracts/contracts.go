@@ -0,0 +1,131 @@
+package contracts
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct {
+	Id string
+}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Backend is an opaque client the analysis cannot see through.
+type Backend interface {
+	Fetch(id string) *Profile
+}
+
+// fetchProfile returns an opaque backend result.
+//
+//grpcnil:nonnil result
+func fetchProfile(b Backend, id string) *Profile {
+	return b.Fetch(id)
+}
+
+// cachedProfile states a contract its body breaks.
+//
+//grpcnil:nonnil result
+func cachedProfile(id string) *Profile {
+	if id == "" {
+		return nil // want "broken nullability contract: cachedProfile is annotated //grpcnil:nonnil result but may return nil"
+	}
+	return &Profile{}
+}
+
+// lookupProfile may not find a profile although its body always does.
+//
+//grpcnil:nullable result
+func lookupProfile(id string) *Profile {
+	return &Profile{}
+}
+
+// wrap requires a non-nil profile.
+//
+//grpcnil:nonnil param p
+func wrap(p *Profile) *Profile {
+	return p
+}
+
+// orDefault accepts nil but forgets to replace it.
+//
+//grpcnil:nonnil result
+//grpcnil:nullable param p
+func orDefault(p *Profile) *Profile {
+	return p // want "broken nullability contract: orDefault is annotated //grpcnil:nonnil result but may return nil"
+}
+
+/* want "malformed directive: misnamed has no parameter q" */ //grpcnil:nonnil param q
+func misnamed(p *Profile) *Profile {
+	return p
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct {
+	backend Backend
+	// fallback is set at startup.
+	fallback *Profile //grpcnil:nonnil
+}
+
+// SetFallback replaces the fallback with a value that may be nil.
+func (s *Service) SetFallback() {
+	s.fallback = maybe() // want "broken nullability contract: field Service.fallback is annotated //grpcnil:nonnil but may be assigned nil"
+}
+
+// GetUserFetched trusts the result contract of fetchProfile.
+func (s *Service) GetUserFetched(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = fetchProfile(s.backend, req.Id)
+	return resp, nil
+}
+
+// GetUserCached trusts the (broken) contract of cachedProfile.
+func (s *Service) GetUserCached(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = cachedProfile(req.Id)
+	return resp, nil
+}
+
+// GetUserLookup uses a result declared nullable.
+func (s *Service) GetUserLookup(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = lookupProfile(req.Id) // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserWrapped passes a maybe-nil value for a non-nil parameter.
+func (s *Service) GetUserWrapped(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = wrap(maybe()) // want "broken nullability contract: parameter p of wrap is annotated //grpcnil:nonnil but may be passed nil"
+	return resp, nil
+}
+
+// GetUserFallback trusts the field contract of fallback.
+func (s *Service) GetUserFallback(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.fallback
+	return resp, nil
+}
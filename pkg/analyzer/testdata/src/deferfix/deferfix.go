@@ -0,0 +1,157 @@
+package deferfix
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with required sub-messages.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+	Meta    *Meta    `protobuf:"bytes,2,opt,name=meta,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// Meta is a nested sub-message type.
+type Meta struct{}
+
+// ProtoMessage marks Meta as a proto message.
+func (*Meta) ProtoMessage() {}
+
+func defaultMeta() *Meta { return &Meta{} }
+
+func maybeMeta() *Meta {
+	if time.Now().Unix()%2 == 0 {
+		return &Meta{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUser fills in Meta from a deferred fix-up.
+func (s *Service) GetUser(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	defer func() {
+		if resp != nil && resp.Meta == nil {
+			resp.Meta = defaultMeta()
+		}
+	}()
+	resp = &GetUserResponse{}
+	resp.Profile = &Profile{}
+	resp.Meta = maybeMeta()
+	return resp, nil
+}
+
+// GetUserDefaults leaves Meta to the deferred fix-up entirely.
+func (s *Service) GetUserDefaults(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	defer func() {
+		if resp.Meta == nil {
+			resp.Meta = defaultMeta()
+		}
+	}()
+	resp = &GetUserResponse{Profile: &Profile{}}
+	return resp, nil
+}
+
+// GetUserNoCheck defers a closure that does not touch Meta.
+func (s *Service) GetUserNoCheck(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	defer func() {
+		if resp != nil && resp.Profile == nil {
+			resp.Profile = &Profile{}
+		}
+	}()
+	resp = &GetUserResponse{Profile: &Profile{}}
+	resp.Meta = maybeMeta() // want "potential nil field in gRPC response GetUserResponse.Meta"
+	return resp, nil
+}
+
+// GetUserMaybeDeferred only defers the fix-up on some paths.
+func (s *Service) GetUserMaybeDeferred(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	if ctx.Err() == nil {
+		defer func() {
+			if resp.Meta == nil {
+				resp.Meta = defaultMeta()
+			}
+		}()
+	}
+	resp = &GetUserResponse{Profile: &Profile{}}
+	resp.Meta = maybeMeta() // want "potential nil field in gRPC response GetUserResponse.Meta"
+	return resp, nil
+}
+
+// GetUserWeakFix patches Meta with a value that may itself be nil.
+func (s *Service) GetUserWeakFix(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	defer func() {
+		if resp.Meta == nil {
+			resp.Meta = maybeMeta() // want "potential nil field in gRPC response GetUserResponse.Meta"
+		}
+	}()
+	resp = &GetUserResponse{Profile: &Profile{}}
+	resp.Meta = maybeMeta() // want "potential nil field in gRPC response GetUserResponse.Meta"
+	return resp, nil
+}
+
+// loadProfile patches its named result before returning.
+func loadProfile() (p *Profile) {
+	defer func() {
+		if p == nil {
+			p = &Profile{}
+		}
+	}()
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// GetUserNamedHelper uses a helper whose deferred fix-up guarantees a value.
+func (s *Service) GetUserNamedHelper(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{Meta: defaultMeta()}
+	resp.Profile = loadProfile()
+	return resp, nil
+}
+
+// GetUserLateStore registers the fix-up after a closure storing a value that
+// may be nil, so the fix-up runs first and the store is what is returned.
+func (s *Service) GetUserLateStore(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	defer func() {
+		resp.Meta = maybeMeta() // want "potential nil field in gRPC response GetUserResponse.Meta"
+	}()
+	defer func() {
+		if resp.Meta == nil {
+			resp.Meta = defaultMeta()
+		}
+	}()
+	resp = &GetUserResponse{Profile: &Profile{}}
+	return resp, nil
+}
+
+// GetUserEarlyStore registers the fix-up first, so it patches the value
+// stored by the closure deferred after it.
+func (s *Service) GetUserEarlyStore(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	defer func() {
+		if resp.Meta == nil {
+			resp.Meta = defaultMeta()
+		}
+	}()
+	defer func() {
+		resp.Meta = maybeMeta()
+	}()
+	resp = &GetUserResponse{Profile: &Profile{}}
+	return resp, nil
+}
@@ -0,0 +1,75 @@
+package defersum
+
+import "time"
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile { // want "summary maybe: MaybeNil"
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+func unlock() {}
+
+func withDefer() *Profile { // want "summary withDefer: NotNil"
+	defer unlock()
+	return &Profile{}
+}
+
+func patched() (p *Profile) { // want "summary patched: NotNil"
+	defer func() {
+		if p == nil {
+			p = &Profile{}
+		}
+	}()
+	return maybe()
+}
+
+func overwritten() (p *Profile) { // want "summary overwritten: NotNil"
+	defer func() {
+		p = &Profile{}
+	}()
+	return nil
+}
+
+func unpatched() (p *Profile) { // want "summary unpatched: MaybeNil"
+	defer func() {
+		_ = p
+	}()
+	return maybe()
+}
+
+func cleared() (p *Profile) { // want "summary cleared: MaybeNil"
+	defer func() {
+		if time.Now().Unix()%2 == 0 {
+			p = nil
+		}
+	}()
+	return &Profile{}
+}
+
+func patchedSometimes(cond bool) (p *Profile) { // want "summary patchedSometimes: MaybeNil"
+	if cond {
+		defer func() {
+			if p == nil {
+				p = &Profile{}
+			}
+		}()
+	}
+	return maybe()
+}
+
+func recovered() (p *Profile) { // want "summary recovered: Unknown"
+	defer func() {
+		if recover() != nil {
+			p = &Profile{}
+		}
+	}()
+	return &Profile{}
+}
@@ -0,0 +1,51 @@
+package descriptorset
+
+import "context"
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse mirrors vendored generated code whose struct tags are out of
+// sync with the proto definition loaded from the descriptor set:
+//   - Profile is `optional` in the .proto, but its tag lacks the oneof marker.
+//   - Avatar is a plain singular message, but its tag claims oneof.
+//   - Settings matches the descriptor.
+type GetUserResponse struct {
+	Profile  *UserProfile `protobuf:"bytes,1,opt,name=profile,proto3"`      // want "field policy mismatch for GetUserResponse.Profile: struct tags classify it as message pointer, descriptor users.v1.GetUserResponse.profile as safe"
+	Avatar   *Image       `protobuf:"bytes,2,opt,name=avatar,proto3,oneof"` // want "field policy mismatch for GetUserResponse.Avatar: struct tags classify it as safe, descriptor users.v1.GetUserResponse.avatar as message pointer"
+	Settings *Settings    `protobuf:"bytes,3,opt,name=settings,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// UserProfile is a nested sub-message type.
+type UserProfile struct{}
+
+// ProtoMessage marks UserProfile as a proto message.
+func (*UserProfile) ProtoMessage() {}
+
+// Image is a nested sub-message type.
+type Image struct{}
+
+// ProtoMessage marks Image as a proto message.
+func (*Image) ProtoMessage() {}
+
+// Settings is a nested sub-message type.
+type Settings struct{}
+
+// ProtoMessage marks Settings as a proto message.
+func (*Settings) ProtoMessage() {}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUser leaves every sub-message unset. Only the fields the descriptor set
+// classifies as required (Avatar, Settings) should be reported.
+func (s *Service) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	return resp, nil // want "implicit nil field in gRPC response GetUserResponse.Avatar" "implicit nil field in gRPC response GetUserResponse.Settings"
+}
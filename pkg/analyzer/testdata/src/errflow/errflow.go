@@ -0,0 +1,92 @@
+package errflow
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// Repo loads profiles.
+type Repo struct{}
+
+// Load returns a nil profile only together with an error.
+func (r *Repo) Load(ctx context.Context) (*Profile, error) { // want Load:"summary MaybeNil; NotNil if err == nil"
+	if time.Now().Unix()%2 == 0 {
+		return nil, errors.New("not found")
+	}
+	return &Profile{}, nil
+}
+
+// LoadLoose may return a nil profile without an error.
+func (r *Repo) LoadLoose(ctx context.Context) (*Profile, error) { // want LoadLoose:"summary MaybeNil"
+	if time.Now().Unix()%2 == 0 {
+		return nil, nil
+	}
+	return &Profile{}, nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct {
+	repo *Repo
+}
+
+// GetUser uses the idiomatic error check and must not be flagged.
+func (s *Service) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p, err := s.repo.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserIgnored discards the error and must be flagged.
+func (s *Service) GetUserIgnored(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p, _ := s.repo.Load(ctx)
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserLoose checks the error of a function that may still return nil
+// and must be flagged.
+func (s *Service) GetUserLoose(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p, err := s.repo.LoadLoose(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserInverted stores on the error path and must be flagged.
+func (s *Service) GetUserInverted(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	p, err := s.repo.Load(ctx)
+	if err != nil {
+		resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	}
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+package errsummary
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+var errNotFound = errors.New("not found")
+
+func lookup() (*Profile, bool) { // want "summary lookup: NotNil"
+	return &Profile{}, time.Now().Unix()%2 == 0
+}
+
+func load() (*Profile, error) { // want "summary load: MaybeNil; NotNil if err == nil"
+	p, ok := lookup()
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return p, nil
+}
+
+func loadWrapped() (*Profile, error) { // want "summary loadWrapped: MaybeNil; NotNil if err == nil"
+	p, err := load()
+	if err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+	return p, nil
+}
+
+func loadLoose() (*Profile, error) { // want "summary loadLoose: MaybeNil"
+	if time.Now().Unix()%2 == 0 {
+		return nil, nil
+	}
+	return &Profile{}, nil
+}
+
+// loadSentinel returns a package-level error, which is not proven non-nil.
+func loadSentinel() (*Profile, error) { // want "summary loadSentinel: MaybeNil"
+	if time.Now().Unix()%2 == 0 {
+		return nil, errNotFound
+	}
+	return &Profile{}, nil
+}
+
+// loadNotFound fails with a constant code other than OK, so the error is
+// never nil alongside a nil profile.
+func loadNotFound() (*Profile, error) { // want "summary loadNotFound: MaybeNil; NotNil if err == nil"
+	p, ok := lookup()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no profile %d", 1)
+	}
+	return p, nil
+}
+
+// loadCode fails with a code that may be OK, making the error nil.
+func loadCode(c codes.Code) (*Profile, error) { // want "summary loadCode: MaybeNil$"
+	p, ok := lookup()
+	if !ok {
+		return nil, status.Error(c, "no profile")
+	}
+	return p, nil
+}
+
+// consume keeps the helpers referenced.
+func consume() {
+	_, _ = loadWrapped()
+	_, _ = loadLoose()
+	_, _ = loadSentinel()
+	_, _ = loadNotFound()
+	_, _ = loadCode(codes.NotFound)
+}
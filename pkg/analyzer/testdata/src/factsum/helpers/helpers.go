@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"time"
+
+	"factsum/pb"
+)
+
+// NewProfile always returns a fresh profile.
+func NewProfile() *pb.Profile { // want NewProfile:"summary NotNil"
+	return &pb.Profile{}
+}
+
+// MaybeProfile returns nil on odd seconds.
+func MaybeProfile() *pb.Profile { // want MaybeProfile:"summary MaybeNil"
+	if time.Now().Unix()%2 == 0 {
+		return &pb.Profile{}
+	}
+	return nil
+}
+
+// FillProfile sets the profile of resp.
+func FillProfile(resp *pb.GetUserResponse) { // want FillProfile:"summary NotNil; sets param 0 Profile: NotNil"
+	resp.Profile = NewProfile()
+}
+
+// FillMaybe sets the profile of resp to a value that may be nil.
+func FillMaybe(resp *pb.GetUserResponse) { // want FillMaybe:"summary NotNil; sets param 0 Profile: MaybeNil"
+	resp.Profile = MaybeProfile()
+}
+
+// SetProfile sets the profile of resp to p.
+func SetProfile(resp *pb.GetUserResponse, p *pb.Profile) { // want SetProfile:"summary NotNil; sets param 0 Profile: param 1"
+	resp.Profile = p
+}
+
+// Fill delegates to SetProfile.
+func Fill(resp *pb.GetUserResponse) { // want Fill:"summary NotNil; sets param 0 Profile: NotNil"
+	SetProfile(resp, &pb.Profile{})
+}
+
+// FillIf sets the profile of resp only when ok.
+func FillIf(resp *pb.GetUserResponse, ok bool) { // want FillIf:"summary NotNil; may set param 0 Profile: NotNil"
+	if ok {
+		resp.Profile = NewProfile()
+	}
+}
@@ -0,0 +1,67 @@
+package svc
+
+import (
+	"context"
+
+	"factsum/helpers"
+	"factsum/pb"
+)
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserFresh uses a helper summarized while analyzing its package.
+func (s *Service) GetUserFresh(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = helpers.NewProfile()
+	return resp, nil
+}
+
+// GetUserMaybe uses a helper whose summary may be nil.
+func (s *Service) GetUserMaybe(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = helpers.MaybeProfile() // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserFilled leaves setting the profile to a helper.
+func (s *Service) GetUserFilled(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	helpers.FillProfile(resp)
+	return resp, nil
+}
+
+// GetUserDelegated leaves setting the profile to a chain of helpers.
+func (s *Service) GetUserDelegated(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	helpers.Fill(resp)
+	return resp, nil
+}
+
+// GetUserFillMaybe leaves setting the profile to a helper that may set nil.
+func (s *Service) GetUserFillMaybe(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	helpers.FillMaybe(resp) // want "potential nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserFillMaybe\\); set by FillMaybe"
+	return resp, nil
+}
+
+// GetUserSet passes the profile to a helper setting it.
+func (s *Service) GetUserSet(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	helpers.SetProfile(resp, helpers.NewProfile())
+	return resp, nil
+}
+
+// GetUserSetNil passes nil to a helper setting the profile.
+func (s *Service) GetUserSetNil(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	helpers.SetProfile(resp, nil) // want "set by SetProfile"
+	return resp, nil
+}
+
+// GetUserFillIf leaves setting the profile to a helper that may skip it.
+func (s *Service) GetUserFillIf(ctx context.Context, req *pb.GetUserRequest, ok bool) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	helpers.FillIf(resp, ok)
+	return resp, nil // want "implicit nil field in gRPC response GetUserResponse.Profile"
+}
@@ -0,0 +1,235 @@
+package fanout
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+	User    *User    `protobuf:"bytes,2,opt,name=user,proto3"`
+	Users   []*User  `protobuf:"bytes,3,rep,name=users,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// User is a message holding a profile.
+type User struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks User as a proto message.
+func (*User) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+func worker(ch chan *Profile) {}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserChan receives a profile sent by a goroutine.
+func (s *Service) GetUserChan(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile, 1)
+	go func() {
+		ch <- &Profile{}
+	}()
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	resp.Profile = <-ch
+	return resp, nil
+}
+
+// GetUserChanMaybe receives a profile that may be nil.
+func (s *Service) GetUserChanMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile, 1)
+	go func() {
+		ch <- maybe()
+	}()
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	resp.Profile = <-ch // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserChanParam hands the channel to a goroutine as an argument.
+func (s *Service) GetUserChanParam(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile, 1)
+	p := &Profile{}
+	go func(out chan<- *Profile, v *Profile) {
+		out <- v
+	}(ch, p)
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	resp.Profile = <-ch
+	return resp, nil
+}
+
+// GetUserChanRange drains a closed channel with a range loop.
+func (s *Service) GetUserChanRange(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile)
+	go func() {
+		defer close(ch)
+		for i := 0; i < 3; i++ {
+			ch <- &Profile{}
+		}
+	}()
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	for p := range ch {
+		resp.Profile = p
+	}
+	return resp, nil
+}
+
+// GetUserChanClosed receives from a channel that may be closed.
+func (s *Service) GetUserChanClosed(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile, 1)
+	go func() {
+		defer close(ch)
+		ch <- &Profile{}
+	}()
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	resp.Profile = <-ch // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserChanEscaped hands the channel to another function.
+func (s *Service) GetUserChanEscaped(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile, 1)
+	go worker(ch)
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	resp.Profile = <-ch // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserSelect receives a profile in a select.
+func (s *Service) GetUserSelect(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile, 1)
+	go func() {
+		ch <- &Profile{}
+	}()
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	select {
+	case p := <-ch:
+		resp.Profile = p
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return resp, nil
+}
+
+// GetUserSelectMaybe receives a profile that may be nil in a select.
+func (s *Service) GetUserSelectMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	ch := make(chan *Profile, 1)
+	go func() {
+		select {
+		case ch <- maybe():
+		case <-ctx.Done():
+		}
+	}()
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	select {
+	case p := <-ch:
+		resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return resp, nil
+}
+
+// GetUserGoroutine fills in the response from a goroutine.
+func (s *Service) GetUserGoroutine(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	p := &Profile{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp.Profile = p
+		resp.User = &User{Profile: &Profile{}}
+	}()
+	<-done
+	return resp, nil
+}
+
+// GetUserGoroutineMaybe stores a profile that may be nil from a goroutine.
+func (s *Service) GetUserGoroutineMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp.Profile = maybe() // want "potential nil field in gRPC response GetUserResponse.Profile"
+		resp.User = &User{Profile: &Profile{}}
+	}()
+	<-done
+	return resp, nil
+}
+
+// GetUserErrgroup collects the response fields in errgroup tasks.
+func (s *Service) GetUserErrgroup(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{Users: make([]*User, 2)}
+	user := &User{}
+	resp.User = user
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		resp.Profile = &Profile{}
+		return nil
+	})
+	g.Go(func() error {
+		user.Profile = &Profile{}
+		return nil
+	})
+	for i := range resp.Users {
+		g.Go(func() error {
+			resp.Users[i] = &User{Profile: &Profile{}}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUserErrgroupMaybe collects values that may be nil in errgroup tasks.
+func (s *Service) GetUserErrgroupMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{Users: make([]*User, 2), Profile: &Profile{}}
+	user := &User{}
+	resp.User = user
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		user.Profile = maybe() // want "potential nil field in gRPC response GetUserResponse.User.Profile"
+		return nil
+	})
+	for i := range resp.Users {
+		g.Go(func() error {
+			var u *User
+			if i > 0 {
+				u = &User{Profile: &Profile{}}
+			}
+			resp.Users[i] = u // want "potential nil element in gRPC response slice GetUserResponse.Users"
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,159 @@
+package fieldflow
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a proto-like request carrying a profile.
+type GetUserRequest struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// User is a message holding a profile.
+type User struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks User as a proto message.
+func (*User) ProtoMessage() {}
+
+// GetProfile is a generated-style getter.
+func (u *User) GetProfile() *Profile { // want GetProfile:"summary MaybeNil"
+	if u != nil {
+		return u.Profile
+	}
+	return nil
+}
+
+type cacheEntry struct {
+	avatar *Profile
+}
+
+type cache struct {
+	entry cacheEntry
+}
+
+type holder struct {
+	user *User
+}
+
+func fill(u *User) {}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserCopied copies a field that was set on a local message.
+func (s *Service) GetUserCopied(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{Profile: &Profile{}}
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile
+	return resp, nil
+}
+
+// GetUserGetter reads the field through its getter.
+func (s *Service) GetUserGetter(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{Profile: &Profile{}}
+	resp := &GetUserResponse{}
+	resp.Profile = user.GetProfile()
+	return resp, nil
+}
+
+// GetUserNested copies a field of a nested struct value.
+func (s *Service) GetUserNested(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var c cache
+	c.entry = cacheEntry{avatar: &Profile{}}
+	resp := &GetUserResponse{}
+	resp.Profile = c.entry.avatar
+	return resp, nil
+}
+
+// GetUserBothBranches sets the field on every path.
+func (s *Service) GetUserBothBranches(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var user User
+	if time.Now().Unix()%2 == 0 {
+		user.Profile = &Profile{}
+	} else {
+		user.Profile = &Profile{}
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile
+	return resp, nil
+}
+
+// GetUserUnset copies a field that was never set and must be flagged.
+func (s *Service) GetUserUnset(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{}
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserOneBranch sets the field on one path only and must be flagged.
+func (s *Service) GetUserOneBranch(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var user User
+	if time.Now().Unix()%2 == 0 {
+		user.Profile = &Profile{}
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserEscaped passes the message to a function that may reset the field
+// and must be flagged.
+func (s *Service) GetUserEscaped(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{Profile: &Profile{}}
+	fill(user)
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserFromRequest copies a field of the request, whose contents are not
+// known, and must be flagged.
+func (s *Service) GetUserFromRequest(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = req.Profile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserHeldCopy resets the field through a copy of the message pointer
+// kept in another struct and must be flagged.
+func (s *Service) GetUserHeldCopy(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{Profile: &Profile{}}
+	h := &holder{user: user}
+	h.user.Profile = nil
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserHeldRead only reads through the copy kept in another struct.
+func (s *Service) GetUserHeldRead(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{Profile: &Profile{}}
+	h := &holder{user: user}
+	if h.user.GetProfile() == nil {
+		return nil, ctx.Err()
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile
+	return resp, nil
+}
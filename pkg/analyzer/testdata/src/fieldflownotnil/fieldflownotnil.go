@@ -0,0 +1,44 @@
+package fieldflownotnil
+
+import "context"
+
+// GetUserRequest is a proto-like request carrying a profile.
+type GetUserRequest struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserFromRequest is analyzed with -field-load-default=notnil and must
+// not be flagged.
+func (s *Service) GetUserFromRequest(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = req.Profile
+	return resp, nil
+}
+
+// GetUserUnset still tracks local messages precisely and must be flagged.
+func (s *Service) GetUserUnset(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &GetUserRequest{}
+	resp := &GetUserResponse{}
+	resp.Profile = user.Profile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+package getterflow
+
+import "context"
+
+// GetUserRequest is a proto-like request carrying a user.
+type GetUserRequest struct {
+	User *User `protobuf:"bytes,1,opt,name=user,proto3"`
+}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUser is a generated-style getter.
+func (x *GetUserRequest) GetUser() *User { // want GetUser:"summary MaybeNil"
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// User is a message holding a profile.
+type User struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks User as a proto message.
+func (*User) ProtoMessage() {}
+
+// GetProfile is a generated-style getter.
+func (x *User) GetProfile() *Profile { // want GetProfile:"summary MaybeNil"
+	if x != nil {
+		return x.Profile
+	}
+	return nil
+}
+
+// Account is a message holding a user.
+type Account struct {
+	User *User `protobuf:"bytes,1,opt,name=user,proto3"`
+}
+
+// ProtoMessage marks Account as a proto message.
+func (*Account) ProtoMessage() {}
+
+// GetUser is a generated-style getter.
+func (x *Account) GetUser() *User { // want GetUser:"summary MaybeNil"
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserSet reads a field that was set through its getter.
+func (s *Service) GetUserSet(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{Profile: &Profile{}}
+	resp := &GetUserResponse{}
+	resp.Profile = user.GetProfile()
+	return resp, nil
+}
+
+// GetUserUnset reads a field that was never set.
+func (s *Service) GetUserUnset(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{}
+	resp := &GetUserResponse{}
+	resp.Profile = user.GetProfile() // want `potential nil field in gRPC response GetUserResponse.Profile \(handler Service.GetUserUnset\); User.Profile may be unset`
+	return resp, nil
+}
+
+// GetUserNilReceiver calls a getter on a nil message.
+func (s *Service) GetUserNilReceiver(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var user *User
+	resp := &GetUserResponse{}
+	resp.Profile = user.GetProfile() // want `potential nil field in gRPC response GetUserResponse.Profile \(handler Service.GetUserNilReceiver\); User is nil`
+	return resp, nil
+}
+
+// GetUserFromRequest reads a field of the request, which may be unset.
+func (s *Service) GetUserFromRequest(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = req.GetUser().GetProfile() // want `potential nil field in gRPC response GetUserResponse.Profile \(handler Service.GetUserFromRequest\); User.Profile may be unset`
+	return resp, nil
+}
+
+// GetUserChain reads through a chain of getters whose fields are all set.
+func (s *Service) GetUserChain(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	account := &Account{User: &User{Profile: &Profile{}}}
+	resp := &GetUserResponse{}
+	resp.Profile = account.GetUser().GetProfile()
+	return resp, nil
+}
+
+// GetUserChainUnset reads through a chain of getters ending in an unset
+// field.
+func (s *Service) GetUserChainUnset(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	account := &Account{User: &User{}}
+	resp := &GetUserResponse{}
+	resp.Profile = account.GetUser().GetProfile() // want `potential nil field in gRPC response GetUserResponse.Profile \(handler Service.GetUserChainUnset\); User.Profile may be unset`
+	return resp, nil
+}
+
+// GetUserChainNilUser reads through a chain of getters whose middle message
+// is unset.
+func (s *Service) GetUserChainNilUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	account := &Account{}
+	resp := &GetUserResponse{}
+	resp.Profile = account.GetUser().GetProfile() // want `potential nil field in gRPC response GetUserResponse.Profile \(handler Service.GetUserChainNilUser\); Account.User may be unset`
+	return resp, nil
+}
+
+// GetUserChainReassigned reads through a chain after the middle message was
+// replaced by one with the field set.
+func (s *Service) GetUserChainReassigned(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	account := &Account{User: &User{}}
+	account.User = &User{Profile: &Profile{}}
+	resp := &GetUserResponse{}
+	resp.Profile = account.GetUser().GetProfile()
+	return resp, nil
+}
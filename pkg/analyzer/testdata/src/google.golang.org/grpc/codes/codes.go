@@ -0,0 +1,11 @@
+// Package codes is a minimal stub of the gRPC status codes.
+package codes
+
+// Code is a gRPC status code.
+type Code uint32
+
+// Status codes used by the fixtures.
+const (
+	OK       Code = 0
+	NotFound Code = 5
+)
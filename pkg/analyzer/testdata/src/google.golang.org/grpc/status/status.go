@@ -0,0 +1,28 @@
+// Package status is a minimal stub of the gRPC status package.
+package status
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+)
+
+type statusError struct {
+	code codes.Code
+	msg  string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// Error returns an error for c, or nil if c is OK.
+func Error(c codes.Code, msg string) error {
+	if c == codes.OK {
+		return nil
+	}
+	return &statusError{code: c, msg: msg}
+}
+
+// Errorf is Error with a formatted message.
+func Errorf(c codes.Code, format string, a ...any) error {
+	return Error(c, fmt.Sprintf(format, a...))
+}
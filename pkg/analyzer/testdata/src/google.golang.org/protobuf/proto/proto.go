@@ -0,0 +1,17 @@
+// Package proto is a minimal stub of google.golang.org/protobuf/proto.
+package proto
+
+// Message is implemented by generated messages.
+type Message interface {
+	ProtoMessage()
+}
+
+// Clone returns a deep copy of m, or nil if m is nil.
+func Clone(m Message) Message {
+	return m
+}
+
+// String returns a pointer to v.
+func String(v string) *string {
+	return &v
+}
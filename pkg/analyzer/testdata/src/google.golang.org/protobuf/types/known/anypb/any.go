@@ -0,0 +1,25 @@
+// Package anypb is a minimal stub of the Any well-known type.
+package anypb
+
+import (
+	"errors"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Any holds an arbitrary serialized message.
+type Any struct {
+	TypeUrl string
+	Value   []byte
+}
+
+// ProtoMessage marks Any as a proto message.
+func (*Any) ProtoMessage() {}
+
+// New marshals src into an Any.
+func New(src proto.Message) (*Any, error) {
+	if src == nil {
+		return nil, errors.New("nil message")
+	}
+	return &Any{}, nil
+}
@@ -0,0 +1,18 @@
+// Package durationpb is a minimal stub of the Duration well-known type.
+package durationpb
+
+import "time"
+
+// Duration is a span of time.
+type Duration struct {
+	Seconds int64
+	Nanos   int32
+}
+
+// ProtoMessage marks Duration as a proto message.
+func (*Duration) ProtoMessage() {}
+
+// New converts d to a Duration.
+func New(d time.Duration) *Duration {
+	return &Duration{Seconds: int64(d / time.Second), Nanos: int32(d % time.Second)}
+}
@@ -0,0 +1,22 @@
+// Package structpb is a minimal stub of the Struct well-known type.
+package structpb
+
+import "errors"
+
+// Struct is a JSON-like object.
+type Struct struct {
+	Fields map[string]any
+}
+
+// ProtoMessage marks Struct as a proto message.
+func (*Struct) ProtoMessage() {}
+
+// NewStruct converts v to a Struct, failing for unsupported values.
+func NewStruct(v map[string]any) (*Struct, error) {
+	for _, x := range v {
+		if x == nil {
+			return nil, errors.New("invalid value")
+		}
+	}
+	return &Struct{Fields: v}, nil
+}
@@ -0,0 +1,23 @@
+// Package timestamppb is a minimal stub of the Timestamp well-known type.
+package timestamppb
+
+import "time"
+
+// Timestamp is a point in time.
+type Timestamp struct {
+	Seconds int64
+	Nanos   int32
+}
+
+// ProtoMessage marks Timestamp as a proto message.
+func (*Timestamp) ProtoMessage() {}
+
+// Now returns the current time.
+func Now() *Timestamp {
+	return New(time.Now())
+}
+
+// New converts t to a Timestamp.
+func New(t time.Time) *Timestamp {
+	return &Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+}
@@ -0,0 +1,15 @@
+// Package wrapperspb is a minimal stub of the wrapper well-known types.
+package wrapperspb
+
+// StringValue wraps a string.
+type StringValue struct {
+	Value string
+}
+
+// ProtoMessage marks StringValue as a proto message.
+func (*StringValue) ProtoMessage() {}
+
+// String wraps v.
+func String(v string) *StringValue {
+	return &StringValue{Value: v}
+}
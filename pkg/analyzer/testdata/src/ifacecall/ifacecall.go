@@ -0,0 +1,102 @@
+package ifacecall
+
+import (
+	"context"
+	"time"
+
+	"ifacecall/mocks"
+	"ifacecall/pb"
+)
+
+// Repo loads profiles.
+type Repo interface {
+	FindProfile(ctx context.Context) *pb.Profile
+	FindMaybe(ctx context.Context) *pb.Profile
+}
+
+type sqlRepo struct{}
+
+func (r *sqlRepo) FindProfile(ctx context.Context) *pb.Profile { // want FindProfile:"summary NotNil"
+	return &pb.Profile{}
+}
+
+func (r *sqlRepo) FindMaybe(ctx context.Context) *pb.Profile { // want FindMaybe:"summary NotNil"
+	return &pb.Profile{}
+}
+
+type cachedRepo struct{}
+
+func (r *cachedRepo) FindProfile(ctx context.Context) *pb.Profile { // want FindProfile:"summary NotNil"
+	return &pb.Profile{}
+}
+
+func (r *cachedRepo) FindMaybe(ctx context.Context) *pb.Profile { // want FindMaybe:"summary MaybeNil"
+	if time.Now().Unix()%2 == 0 {
+		return &pb.Profile{}
+	}
+	return nil
+}
+
+func freshProfile(ctx context.Context) *pb.Profile {
+	return &pb.Profile{}
+}
+
+func maybeProfile(ctx context.Context, fallback bool) *pb.Profile {
+	if fallback {
+		return &pb.Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct {
+	repo  Repo
+	load  func(ctx context.Context) *pb.Profile
+	maybe func(ctx context.Context, fallback bool) *pb.Profile
+}
+
+// NewService wires the production dependencies.
+func NewService(cached bool) *Service { // want NewService:"summary NotNil"
+	s := &Service{load: freshProfile, maybe: maybeProfile}
+	if cached {
+		s.repo = &cachedRepo{}
+	} else {
+		s.repo = &sqlRepo{}
+	}
+	return s
+}
+
+// NewTestService wires a test double, which is excluded from the analysis.
+func NewTestService() *Service { // want NewTestService:"summary NotNil"
+	return &Service{repo: &mocks.MockRepo{}, load: freshProfile, maybe: maybeProfile}
+}
+
+// GetUser calls an interface method whose implementations never return nil.
+func (s *Service) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = s.repo.FindProfile(ctx)
+	return resp, nil
+}
+
+// GetUserMaybe calls an interface method with an implementation that can
+// return nil and must be flagged.
+func (s *Service) GetUserMaybe(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = s.repo.FindMaybe(ctx) // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserLoaded calls a function value that never returns nil.
+func (s *Service) GetUserLoaded(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = s.load(ctx)
+	return resp, nil
+}
+
+// GetUserFallback calls a function value that can return nil and must be
+// flagged.
+func (s *Service) GetUserFallback(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = s.maybe(ctx, false) // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"context"
+
+	"ifacecall/pb"
+)
+
+// MockRepo is a test double returning nothing.
+type MockRepo struct{}
+
+// FindProfile returns nil.
+func (m *MockRepo) FindProfile(ctx context.Context) *pb.Profile {
+	return nil
+}
+
+// FindMaybe returns nil.
+func (m *MockRepo) FindMaybe(ctx context.Context) *pb.Profile {
+	return nil
+}
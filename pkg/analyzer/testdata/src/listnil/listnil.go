@@ -44,6 +44,6 @@ func (s *Service) ListUsers(ctx context.Context, req *ListUsersRequest) (*ListUs
 	resp := &ListUsersResponse{
 		Users: make([]*User, 1),
 	}
-	resp.Users[0] = maybeUser() // want "potential nil element in gRPC response slice Users"
+	resp.Users[0] = maybeUser() // want "potential nil element in gRPC response slice ListUsersResponse.Users \\(handler Service.ListUsers\\)"
 	return resp, nil
 }
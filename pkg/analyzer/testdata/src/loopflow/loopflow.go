@@ -0,0 +1,125 @@
+package loopflow
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct {
+	Count int32 `protobuf:"varint,1,opt,name=count,proto3"`
+}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct {
+	Version int32 `protobuf:"varint,1,opt,name=version,proto3"`
+}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func next(p *Profile) *Profile {
+	return &Profile{Version: p.Version + 1}
+}
+
+func same(p *Profile) *Profile {
+	return p
+}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserLoop advances a pointer that is non-nil on every iteration.
+func (s *Service) GetUserLoop(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := &Profile{}
+	for i := int32(0); i < req.Count; i++ {
+		p = next(p)
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserLoopPassThrough carries the pointer through a callee returning its
+// argument.
+func (s *Service) GetUserLoopPassThrough(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := &Profile{}
+	for i := int32(0); i < req.Count; i++ {
+		if i%2 == 0 {
+			p = same(p)
+		} else {
+			p = next(p)
+		}
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserNestedLoops advances the pointer in nested loops.
+func (s *Service) GetUserNestedLoops(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := &Profile{}
+	for i := int32(0); i < req.Count; i++ {
+		for j := int32(0); j < i; j++ {
+			p = next(p)
+		}
+		p = same(p)
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserLoopMaybe may replace the pointer by nil on some iteration.
+func (s *Service) GetUserLoopMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := &Profile{}
+	for i := int32(0); i < req.Count; i++ {
+		p = same(maybe())
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserLoopFromNil starts from nil and is only set inside the loop.
+func (s *Service) GetUserLoopFromNil(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	for i := int32(0); i < req.Count; i++ {
+		p = next(p)
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserLoopChecked recovers from nil inside the loop.
+func (s *Service) GetUserLoopChecked(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	for i := int32(0); i < req.Count || p == nil; i++ {
+		if p == nil {
+			p = &Profile{}
+		}
+		p = same(p)
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
@@ -0,0 +1,82 @@
+package nesteddepth
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	User *User `protobuf:"bytes,1,opt,name=user,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// User is a sub-message one level below the response.
+type User struct {
+	Profile *Profile  `protobuf:"bytes,1,opt,name=profile,proto3"`
+	Friends []*Friend `protobuf:"bytes,2,rep,name=friends,proto3"`
+}
+
+// ProtoMessage marks User as a proto message.
+func (*User) ProtoMessage() {}
+
+// Profile is a sub-message two levels below the response.
+type Profile struct {
+	Avatar *Image `protobuf:"bytes,1,opt,name=avatar,proto3"`
+}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// Friend is an element of a repeated sub-message field.
+type Friend struct{}
+
+// ProtoMessage marks Friend as a proto message.
+func (*Friend) ProtoMessage() {}
+
+// Image is a sub-message three levels below the response.
+type Image struct{}
+
+// ProtoMessage marks Image as a proto message.
+func (*Image) ProtoMessage() {}
+
+func maybeFriend() *Friend {
+	if time.Now().Unix()%2 == 0 {
+		return &Friend{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUser leaves Profile.Avatar unset, which is below -max-nested-depth 1.
+func (s *Service) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.User = &User{Profile: &Profile{}}
+	return resp, nil
+}
+
+// GetUserUnsetProfile leaves User.Profile unset, within the limit.
+func (s *Service) GetUserUnsetProfile(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.User = &User{} // want "implicit nil field in gRPC response GetUserResponse.User.Profile"
+	return resp, nil
+}
+
+// GetUserFriends stores a maybe-nil element into a nested repeated field.
+func (s *Service) GetUserFriends(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	u := &User{Profile: &Profile{}, Friends: make([]*Friend, 1)}
+	u.Friends[0] = maybeFriend() // want "potential nil element in gRPC response slice GetUserResponse.User.Friends \\(handler Service.GetUserFriends\\)"
+	resp.User = u
+	return resp, nil
+}
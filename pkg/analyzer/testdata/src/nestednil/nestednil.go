@@ -0,0 +1,99 @@
+package nestednil // want package:"requires GetUserResponse.User at nestednil.go:76"
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse wraps a single User.
+type GetUserResponse struct {
+	User *User `protobuf:"bytes,1,opt,name=user,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// ListUsersResponse holds a repeated User field.
+type ListUsersResponse struct {
+	Users []*User `protobuf:"bytes,1,rep,name=users,proto3"`
+}
+
+// ProtoMessage marks ListUsersResponse as a proto message.
+func (*ListUsersResponse) ProtoMessage() {}
+
+// User has two required sub-messages and an optional, recursive Manager.
+type User struct {
+	Profile   *Profile   `protobuf:"bytes,1,opt,name=profile,proto3"`
+	CreatedAt *Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3"`
+	Manager   *User      `protobuf:"bytes,3,opt,name=manager,proto3,oneof"`
+}
+
+// ProtoMessage marks User as a proto message.
+func (*User) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+// Timestamp is a proto-like date message.
+type Timestamp struct{}
+
+// ProtoMessage marks Timestamp as a proto message.
+func (*Timestamp) ProtoMessage() {}
+
+func maybeProfile() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUser places a User into the response whose Profile may be nil and whose
+// CreatedAt is never set.
+func (s *Service) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	return &GetUserResponse{
+		User: &User{ // want "implicit nil field in gRPC response GetUserResponse.User.CreatedAt"
+			Profile: maybeProfile(), // want "potential nil field in gRPC response GetUserResponse.User.Profile"
+		},
+	}, nil
+}
+
+// GetUserComplete fills every required field, including through a reload of
+// the response field.
+func (s *Service) GetUserComplete(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{User: &User{Profile: &Profile{}}}
+	resp.User.CreatedAt = &Timestamp{}
+	return resp, nil
+}
+
+// GetUserWithManager sets the optional Manager, whose own required fields
+// must then be present. The self-reference must not loop forever.
+func (s *Service) GetUserWithManager(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	user := &User{Profile: &Profile{}, CreatedAt: &Timestamp{}}
+	manager := &User{CreatedAt: &Timestamp{}}
+	manager.Manager = user
+	user.Manager = manager // want "implicit nil field in gRPC response GetUserResponse.User.Manager.Profile"
+	return &GetUserResponse{User: user}, nil
+}
+
+// ListUsers builds the repeated field as a composite literal; element paths
+// carry the index.
+func (s *Service) ListUsers(ctx context.Context, req *GetUserRequest) (*ListUsersResponse, error) {
+	return &ListUsersResponse{
+		Users: []*User{
+			{Profile: &Profile{}, CreatedAt: &Timestamp{}},
+			{Profile: &Profile{}}, // want `implicit nil field in gRPC response ListUsersResponse.Users\[1\].CreatedAt`
+		},
+	}, nil
+}
@@ -0,0 +1,106 @@
+package nilcheck
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserDefaulted replaces a nil value before storing it.
+func (s *Service) GetUserDefaulted(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	p := maybe()
+	if p == nil {
+		p = &Profile{}
+	}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserGuarded only stores the value inside a nil check.
+func (s *Service) GetUserGuarded(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	if p := maybe(); p != nil {
+		resp.Profile = p
+	}
+	return resp, nil
+}
+
+// GetUserEarlyReturn returns an error before a nil value can be stored.
+func (s *Service) GetUserEarlyReturn(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := maybe()
+	if p == nil {
+		return nil, errors.New("no profile")
+	}
+	return &GetUserResponse{Profile: p}, nil
+}
+
+// GetUserAnd checks both values in a && chain.
+func (s *Service) GetUserAnd(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	a, b := maybe(), maybe()
+	if a != nil && b != nil {
+		resp.Profile = b
+	}
+	return resp, nil
+}
+
+// GetUserOr rejects either value being nil in a || chain.
+func (s *Service) GetUserOr(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	a, b := maybe(), maybe()
+	if a == nil || b == nil {
+		return nil, errors.New("no profile")
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = a
+	return resp, nil
+}
+
+// GetUserInverted stores on the nil branch and must be flagged.
+func (s *Service) GetUserInverted(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	if p := maybe(); p == nil {
+		resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	}
+	return resp, nil
+}
+
+// GetUserWrongValue checks one value but stores another and must be flagged.
+func (s *Service) GetUserWrongValue(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	a, b := maybe(), maybe()
+	if a != nil {
+		resp.Profile = b // want "potential nil field in gRPC response GetUserResponse.Profile"
+	}
+	return resp, nil
+}
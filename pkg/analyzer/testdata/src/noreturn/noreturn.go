@@ -0,0 +1,108 @@
+package noreturn
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Fatal reports msg and aborts the request.
+func Fatal(msg string) { // want Fatal:"summary never returns"
+	fail(msg)
+}
+
+func fail(msg string) {
+	panic("noreturn: " + msg)
+}
+
+func mustProfile(p *Profile) *Profile {
+	if p == nil {
+		fail("missing profile")
+	}
+	return p
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserPanicHelper calls a helper that always panics.
+func (s *Service) GetUserPanicHelper(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := maybe()
+	if p == nil {
+		fail("missing profile")
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserExportedFatal calls an exported helper that never returns.
+func (s *Service) GetUserExportedFatal(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := maybe()
+	if p == nil {
+		Fatal("missing profile")
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserMust uses a helper that panics instead of returning nil.
+func (s *Service) GetUserMust(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = mustProfile(maybe())
+	return resp, nil
+}
+
+// GetUserSwitch leaves the profile nil only on a path that exits.
+func (s *Service) GetUserSwitch(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	switch time.Now().Unix() % 3 {
+	case 0:
+		p = &Profile{}
+	case 1:
+		p = &Profile{}
+	default:
+		fail("unexpected")
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserUnchecked ignores a missing profile and must be flagged.
+func (s *Service) GetUserUnchecked(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := maybe()
+	if p == nil {
+		_ = ctx.Err()
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
@@ -0,0 +1,51 @@
+package noreturncfg
+
+import (
+	"context"
+	"time"
+
+	"zaplike"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct {
+	log *zaplike.Logger
+}
+
+// GetUserConfigured calls a logger configured with -no-return.
+func (s *Service) GetUserConfigured(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p := maybe()
+	if p == nil {
+		s.log.Fatal("missing profile")
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
@@ -0,0 +1,50 @@
+package noreturnsum
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func fatalProfile() *Profile { // want "summary fatalProfile: never returns"
+	log.Fatal("no profile")
+	return nil
+}
+
+func exitIfNil(p *Profile) *Profile { // want "summary exitIfNil: NotNil"
+	if p == nil {
+		os.Exit(1)
+	}
+	return p
+}
+
+func fatalOnDefault() *Profile { // want "summary fatalOnDefault: NotNil"
+	var p *Profile
+	switch time.Now().Unix() % 3 {
+	case 0:
+		p = &Profile{}
+	case 1:
+		p = &Profile{}
+	default:
+		log.Fatalf("unexpected")
+	}
+	return p
+}
+
+func logIfNil(p *Profile) *Profile { // want "summary logIfNil: returns param 0"
+	if p == nil {
+		log.Printf("no profile")
+	}
+	return p
+}
+
+func pattern() *regexp.Regexp { // want "summary pattern: NotNil"
+	return regexp.MustCompile("^[a-z]+$")
+}
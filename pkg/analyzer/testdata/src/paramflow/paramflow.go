@@ -0,0 +1,82 @@
+package paramflow
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func identity(p *Profile) *Profile {
+	return p
+}
+
+func orDefault(p *Profile) *Profile {
+	if p == nil {
+		return &Profile{}
+	}
+	return p
+}
+
+func firstNonNil(a, b *Profile) *Profile {
+	if a != nil {
+		return a
+	}
+	return b
+}
+
+func fresh() *Profile {
+	return &Profile{}
+}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserForwarded passes a non-nil value through identity-style helpers,
+// which must not be flagged.
+func (s *Service) GetUserForwarded(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = firstNonNil(identity(fresh()), orDefault(fresh()))
+	return resp, nil
+}
+
+// GetUserForwardedNil passes a maybe-nil value through identity, which must
+// be flagged.
+func (s *Service) GetUserForwardedNil(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = identity(maybe()) // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserDefaulted falls back to a default for a nil argument, which must
+// not be flagged.
+func (s *Service) GetUserDefaulted(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = orDefault(nil)
+	return resp, nil
+}
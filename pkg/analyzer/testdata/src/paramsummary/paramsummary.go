@@ -0,0 +1,58 @@
+package paramsummary
+
+import "time"
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func identity(p *Profile) *Profile { // want "summary identity: returns param 0"
+	return p
+}
+
+func orDefault(p *Profile) *Profile { // want "summary orDefault: NotNil"
+	if p == nil {
+		return &Profile{}
+	}
+	return p
+}
+
+func firstNonNil(a, b *Profile) *Profile { // want "summary firstNonNil: non-nil if param 1 non-nil"
+	if a != nil {
+		return a
+	}
+	return b
+}
+
+func sometimesFresh(p *Profile) *Profile { // want "summary sometimesFresh: non-nil if param 0 non-nil"
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return p
+}
+
+func either(a, b *Profile) *Profile { // want "summary either: returns param 0 or param 1"
+	if time.Now().Unix()%2 == 0 {
+		return a
+	}
+	return b
+}
+
+func fresh() *Profile { // want "summary fresh: NotNil"
+	return &Profile{}
+}
+
+func maybe() *Profile { // want "summary maybe: MaybeNil"
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// consume keeps the helpers referenced.
+func consume() {
+	_ = firstNonNil(identity(fresh()), orDefault(maybe()))
+	_ = either(sometimesFresh(nil), nil)
+}
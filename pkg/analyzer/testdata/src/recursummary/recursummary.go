@@ -0,0 +1,70 @@
+package recursummary
+
+import "time"
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func cond() bool { return time.Now().Unix()%2 == 0 }
+
+func fresh() *Profile { // want "summary fresh: NotNil"
+	return &Profile{}
+}
+
+// merged returns a Phi of two allocations.
+func merged() *Profile { // want "summary merged: NotNil"
+	var p *Profile
+	if cond() {
+		p = &Profile{}
+	} else {
+		p = fresh()
+	}
+	return p
+}
+
+// wrapped returns the result of another non-nil constructor.
+func wrapped() *Profile { // want "summary wrapped: NotNil"
+	return merged()
+}
+
+// even and odd are mutually recursive and non-nil on every path.
+func even(n int) *Profile { // want "summary even: NotNil"
+	if n == 0 {
+		return fresh()
+	}
+	return odd(n - 1)
+}
+
+func odd(n int) *Profile { // want "summary odd: NotNil"
+	if n == 0 {
+		return &Profile{}
+	}
+	return even(n - 1)
+}
+
+// nilChain only ever bottoms out in nil.
+func nilChain(n int) *Profile { // want "summary nilChain: DefinitelyNil"
+	if n == 0 {
+		return nil
+	}
+	return nilChain(n - 1)
+}
+
+// forward recursively forwards its parameter.
+func forward(p *Profile, n int) *Profile { // want "summary forward: returns param 0"
+	if n == 0 {
+		return p
+	}
+	return forward(p, n-1)
+}
+
+// mixed recurses through a helper that may return nil.
+func mixed(n int) *Profile { // want "summary mixed: MaybeNil"
+	if n == 0 {
+		return nilChain(n)
+	}
+	return even(n)
+}
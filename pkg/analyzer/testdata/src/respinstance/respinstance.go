@@ -0,0 +1,130 @@
+package respinstance
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+var lastResponse *GetUserResponse
+
+func record(resp *GetUserResponse) {
+	lastResponse = resp
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserScratch records a scratch response whose Profile may be nil, and
+// returns a different, complete one.
+func (s *Service) GetUserScratch(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	scratch := &GetUserResponse{}
+	scratch.Profile = maybe()
+	record(scratch)
+	resp := &GetUserResponse{}
+	resp.Profile = &Profile{}
+	return resp, nil
+}
+
+// GetUserScratchFilled fills only the scratch response.
+func (s *Service) GetUserScratchFilled(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	scratch := &GetUserResponse{}
+	scratch.Profile = &Profile{}
+	record(scratch)
+	resp := &GetUserResponse{}
+	return resp, nil // want "implicit nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserScratchFilled\\)"
+}
+
+// GetUserMerged returns one of two responses, only one of them complete.
+func (s *Service) GetUserMerged(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var resp *GetUserResponse
+	if ctx.Err() == nil {
+		resp = &GetUserResponse{}
+		resp.Profile = &Profile{}
+	} else {
+		resp = &GetUserResponse{}
+	}
+	return resp, nil // want "implicit nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserMerged\\)"
+}
+
+// GetUserMergedComplete returns one of two complete responses.
+func (s *Service) GetUserMergedComplete(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var resp *GetUserResponse
+	if ctx.Err() == nil {
+		resp = &GetUserResponse{Profile: &Profile{}}
+	} else {
+		resp = &GetUserResponse{}
+		resp.Profile = &Profile{}
+	}
+	return resp, nil
+}
+
+// GetUserEarlyReturn returns an incomplete response on one path only.
+func (s *Service) GetUserEarlyReturn(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	if ctx.Err() != nil {
+		return &GetUserResponse{}, nil // want "implicit nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserEarlyReturn\\)"
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = &Profile{}
+	return resp, nil
+}
+
+// GetUserNamed fills its named result.
+func (s *Service) GetUserNamed(ctx context.Context, req *GetUserRequest) (resp *GetUserResponse, err error) {
+	resp = &GetUserResponse{}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	resp.Profile = &Profile{}
+	return resp, nil
+}
+
+func build() *GetUserResponse {
+	return &GetUserResponse{Profile: &Profile{}}
+}
+
+// GetUserScratchBuilt records a scratch response whose Profile may be nil,
+// and returns one built by a helper.
+func (s *Service) GetUserScratchBuilt(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	scratch := &GetUserResponse{}
+	scratch.Profile = maybe()
+	record(scratch)
+	return build(), nil
+}
+
+// GetUserBuiltOrLocal returns a built response on one path and a local one
+// on the other; the store into the local one is reported once.
+func (s *Service) GetUserBuiltOrLocal(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	if ctx.Err() != nil {
+		return build(), nil
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = maybe() // want "potential nil field in gRPC response GetUserResponse.Profile \\(handler Service.GetUserBuiltOrLocal\\)"
+	return resp, nil
+}
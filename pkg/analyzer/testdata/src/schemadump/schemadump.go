@@ -0,0 +1,21 @@
+package schemadump
+
+// Node is a recursive proto-like message: a tree of nodes with metadata.
+type Node struct {
+	Name     string           `protobuf:"bytes,1,opt,name=name,proto3"`
+	Parent   *Node            `protobuf:"bytes,2,opt,name=parent,proto3"`
+	Children []*Node          `protobuf:"bytes,3,rep,name=children,proto3"`
+	Meta     *Meta            `protobuf:"bytes,4,opt,name=meta,proto3,oneof"`
+	Attrs    map[string]*Meta `protobuf:"bytes,5,rep,name=attrs,proto3"`
+}
+
+// ProtoMessage marks Node as a proto message.
+func (*Node) ProtoMessage() {}
+
+// Meta is a leaf sub-message.
+type Meta struct {
+	Owner string `protobuf:"bytes,1,opt,name=owner,proto3"`
+}
+
+// ProtoMessage marks Meta as a proto message.
+func (*Meta) ProtoMessage() {}
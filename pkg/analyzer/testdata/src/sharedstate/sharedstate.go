@@ -0,0 +1,179 @@
+package sharedstate
+
+import (
+	"context"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+var (
+	defaultProfile  = &Profile{}
+	initProfile     *Profile
+	resetProfile    = &Profile{}
+	unsetProfile    *Profile
+	fallbackProfile *Profile
+)
+
+func init() {
+	initProfile = &Profile{}
+	if time.Now().Unix()%2 == 0 {
+		fallbackProfile = &Profile{}
+	}
+}
+
+// Reset clears resetProfile after initialization.
+func Reset() {
+	resetProfile = nil
+}
+
+// DefaultProfile is exported, so other packages may reassign it.
+var DefaultProfile = &Profile{}
+
+// server is initialized by NewServer on every path.
+type server struct {
+	defaultProfile *Profile
+	cachedProfile  *Profile
+	spareProfile   *Profile
+	backupProfile  *Profile
+	cache          *Cache
+}
+
+// NewServer always sets defaultProfile and spareProfile, but cachedProfile
+// only sometimes.
+func NewServer() *server { // want NewServer:"summary NotNil"
+	s := &server{spareProfile: &Profile{}, backupProfile: &Profile{}, cache: NewCache()}
+	s.defaultProfile = &Profile{}
+	if time.Now().Unix()%2 == 0 {
+		s.cachedProfile = &Profile{}
+	}
+	return s
+}
+
+// Cache is exported, so other packages may build one as &Cache{} or
+// new(Cache), leaving profile nil.
+type Cache struct {
+	profile *Profile
+}
+
+// NewCache always sets profile.
+func NewCache() *Cache { // want NewCache:"summary NotNil"
+	return &Cache{profile: &Profile{}}
+}
+
+// Reload replaces spareProfile with a value that may be nil.
+func (s *server) Reload() {
+	s.spareProfile = maybe()
+}
+
+func clearProfile(pp **Profile) {
+	*pp = nil
+}
+
+// Drop clears backupProfile through its address.
+func (s *server) Drop() {
+	clearProfile(&s.backupProfile)
+}
+
+// GetUserDefault uses a package variable initialized non-nil.
+func (s *server) GetUserDefault(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = defaultProfile
+	return resp, nil
+}
+
+// GetUserInit uses a package variable set by init.
+func (s *server) GetUserInit(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = initProfile
+	return resp, nil
+}
+
+// GetUserReset uses a package variable that is cleared elsewhere.
+func (s *server) GetUserReset(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = resetProfile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserUnset uses a package variable that is never assigned.
+func (s *server) GetUserUnset(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = unsetProfile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserFallback uses a package variable init sets conditionally.
+func (s *server) GetUserFallback(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = fallbackProfile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserService uses a field the constructor always sets.
+func (s *server) GetUserService(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.defaultProfile
+	return resp, nil
+}
+
+// GetUserCached uses a field the constructor sets conditionally.
+func (s *server) GetUserCached(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.cachedProfile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserSpare uses a field reassigned by Reload.
+func (s *server) GetUserSpare(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.spareProfile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserExportedVar uses an exported package variable.
+func (s *server) GetUserExportedVar(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = DefaultProfile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserExportedType uses a field of an exported type.
+func (s *server) GetUserExportedType(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.cache.profile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserBackup uses a field the constructor sets but Drop clears through
+// its address.
+func (s *server) GetUserBackup(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = s.backupProfile // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
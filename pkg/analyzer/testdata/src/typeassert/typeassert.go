@@ -0,0 +1,99 @@
+package typeassert
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with a required sub-message.
+type GetUserResponse struct {
+	Profile *Profile `protobuf:"bytes,1,opt,name=profile,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// Profile is a nested sub-message type.
+type Profile struct{}
+
+// ProtoMessage marks Profile as a proto message.
+func (*Profile) ProtoMessage() {}
+
+type message interface {
+	ProtoMessage()
+}
+
+type profileKey struct{}
+
+func maybe() *Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUserFromContext asserts a context value, which panics rather than
+// yielding nil.
+func (s *Service) GetUserFromContext(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	resp.Profile = ctx.Value(profileKey{}).(*Profile)
+	return resp, nil
+}
+
+// GetUserCheckedOk returns early when the assertion fails.
+func (s *Service) GetUserCheckedOk(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p, ok := ctx.Value(profileKey{}).(*Profile)
+	if !ok {
+		return nil, errors.New("no profile")
+	}
+	resp := &GetUserResponse{}
+	resp.Profile = p
+	return resp, nil
+}
+
+// GetUserGuardedOk stores only when the assertion succeeded.
+func (s *Service) GetUserGuardedOk(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	resp := &GetUserResponse{}
+	if p, ok := ctx.Value(profileKey{}).(*Profile); ok {
+		resp.Profile = p
+	}
+	return resp, nil
+}
+
+// GetUserIgnoredOk ignores a failed assertion and must be flagged.
+func (s *Service) GetUserIgnoredOk(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	p, _ := ctx.Value(profileKey{}).(*Profile)
+	resp := &GetUserResponse{}
+	resp.Profile = p // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserTypedNil asserts an interface holding a typed nil and must be
+// flagged.
+func (s *Service) GetUserTypedNil(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var p *Profile
+	var m message = p
+	resp := &GetUserResponse{}
+	resp.Profile = m.(*Profile) // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
+
+// GetUserWrappedMaybe asserts through an interface conversion chain around a
+// value that can be nil and must be flagged.
+func (s *Service) GetUserWrappedMaybe(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	var m message = maybe()
+	var anyMsg any = m
+	resp := &GetUserResponse{}
+	resp.Profile = anyMsg.(message).(*Profile) // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
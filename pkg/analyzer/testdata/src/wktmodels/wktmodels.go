@@ -0,0 +1,107 @@
+package wktmodels
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// GetUserRequest is a minimal proto-like request message.
+type GetUserRequest struct{}
+
+// ProtoMessage marks GetUserRequest as a proto message.
+func (*GetUserRequest) ProtoMessage() {}
+
+// GetUserResponse is a proto-like response with well-known type fields.
+type GetUserResponse struct {
+	CreatedAt *timestamppb.Timestamp  `protobuf:"bytes,1,opt,name=created_at,proto3"`
+	UpdatedAt *timestamppb.Timestamp  `protobuf:"bytes,2,opt,name=updated_at,proto3"`
+	Ttl       *durationpb.Duration    `protobuf:"bytes,3,opt,name=ttl,proto3"`
+	Nickname  *wrapperspb.StringValue `protobuf:"bytes,4,opt,name=nickname,proto3"`
+	Attrs     *structpb.Struct        `protobuf:"bytes,5,opt,name=attrs,proto3"`
+	Details   *anypb.Any              `protobuf:"bytes,6,opt,name=details,proto3"`
+}
+
+// ProtoMessage marks GetUserResponse as a proto message.
+func (*GetUserResponse) ProtoMessage() {}
+
+// GetNicknameResponse is a proto-like response with a single wrapper field.
+type GetNicknameResponse struct {
+	Nickname *wrapperspb.StringValue `protobuf:"bytes,1,opt,name=nickname,proto3"`
+}
+
+// ProtoMessage marks GetNicknameResponse as a proto message.
+func (*GetNicknameResponse) ProtoMessage() {}
+
+func maybeNickname() *wrapperspb.StringValue {
+	if time.Now().Unix()%2 == 0 {
+		return wrapperspb.String("nick")
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct{}
+
+// GetUser fills every field from modeled constructors, checking errors.
+func (s *Service) GetUser(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	attrs, err := structpb.NewStruct(map[string]any{"a": 1})
+	if err != nil {
+		return nil, err
+	}
+	details, err := anypb.New(req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &GetUserResponse{}
+	resp.CreatedAt = timestamppb.Now()
+	resp.UpdatedAt = timestamppb.New(time.Now())
+	resp.Ttl = durationpb.New(time.Minute)
+	resp.Nickname = wrapperspb.String("nick")
+	resp.Attrs = attrs
+	resp.Details = details
+	return resp, nil
+}
+
+// GetUserUnchecked ignores the errors of constructors that fail with nil.
+func (s *Service) GetUserUnchecked(ctx context.Context, req *GetUserRequest) (*GetUserResponse, error) {
+	attrs, _ := structpb.NewStruct(map[string]any{"a": 1})
+	details, _ := anypb.New(req)
+	resp := &GetUserResponse{}
+	resp.CreatedAt = timestamppb.Now()
+	resp.UpdatedAt = timestamppb.Now()
+	resp.Ttl = durationpb.New(time.Minute)
+	resp.Nickname = wrapperspb.String("nick")
+	resp.Attrs = attrs     // want "potential nil field in gRPC response GetUserResponse.Attrs"
+	resp.Details = details // want "potential nil field in gRPC response GetUserResponse.Details"
+	return resp, nil
+}
+
+// GetNicknameClone copies a nickname that is always set.
+func (s *Service) GetNicknameClone(ctx context.Context, req *GetUserRequest) (*GetNicknameResponse, error) {
+	resp := &GetNicknameResponse{}
+	resp.Nickname = proto.Clone(wrapperspb.String("nick")).(*wrapperspb.StringValue)
+	return resp, nil
+}
+
+// GetNicknameCloneMaybe copies a nickname that may be nil; the copy of a
+// typed nil is nil again.
+func (s *Service) GetNicknameCloneMaybe(ctx context.Context, req *GetUserRequest) (*GetNicknameResponse, error) {
+	resp := &GetNicknameResponse{}
+	resp.Nickname = proto.Clone(maybeNickname()).(*wrapperspb.StringValue) // want "potential nil field in gRPC response GetNicknameResponse.Nickname"
+	return resp, nil
+}
+
+// GetNicknameCloneNil copies a nil nickname.
+func (s *Service) GetNicknameCloneNil(ctx context.Context, req *GetUserRequest) (*GetNicknameResponse, error) {
+	var nick *wrapperspb.StringValue
+	resp := &GetNicknameResponse{}
+	resp.Nickname = proto.Clone(nick).(*wrapperspb.StringValue) // want "potential nil field in gRPC response GetNicknameResponse.Nickname"
+	return resp, nil
+}
@@ -0,0 +1,23 @@
+package clib
+
+import "xcontracts/pb"
+
+// Client caches the profile of the current user, which is always set.
+type Client struct {
+	//grpcnil:nonnil
+	Cached *pb.Profile // want Cached:"contract nonnil"
+}
+
+// Wrap returns p, which callers must not pass as nil.
+//
+//grpcnil:nonnil param p
+func Wrap(p *pb.Profile) *pb.Profile { // want Wrap:"summary NotNil" Wrap:"contract nonnil param p"
+	return p
+}
+
+// Lookup is declared to possibly return nil.
+//
+//grpcnil:nullable result
+func Lookup() *pb.Profile { // want Lookup:"summary MaybeNil \\(contract\\)" Lookup:"contract nullable result"
+	return &pb.Profile{}
+}
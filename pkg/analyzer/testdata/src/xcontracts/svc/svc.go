@@ -0,0 +1,58 @@
+package svc
+
+import (
+	"context"
+	"time"
+
+	"xcontracts/clib"
+	"xcontracts/pb"
+)
+
+func maybe() *pb.Profile {
+	if time.Now().Unix()%2 == 0 {
+		return &pb.Profile{}
+	}
+	return nil
+}
+
+// Service is a minimal gRPC-like service implementation.
+type Service struct {
+	client *clib.Client
+}
+
+// GetUserWrapped passes a value that may be nil to a non-nil parameter of
+// another package.
+func (s *Service) GetUserWrapped(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = clib.Wrap(maybe()) // want "broken nullability contract: parameter p of Wrap is annotated //grpcnil:nonnil but may be passed nil"
+	return resp, nil
+}
+
+// GetUserWrappedFresh passes a fresh value to the same parameter.
+func (s *Service) GetUserWrappedFresh(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = clib.Wrap(&pb.Profile{})
+	return resp, nil
+}
+
+// GetUserCached trusts the field contract declared in another package.
+func (s *Service) GetUserCached(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = s.client.Cached
+	return resp, nil
+}
+
+// GetUserRecache stores a value that may be nil into that field.
+func (s *Service) GetUserRecache(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	s.client.Cached = maybe() // want "broken nullability contract: field Client.Cached is annotated //grpcnil:nonnil but may be assigned nil"
+	resp := &pb.GetUserResponse{}
+	resp.Profile = &pb.Profile{}
+	return resp, nil
+}
+
+// GetUserLookup uses a function declared nullable in another package.
+func (s *Service) GetUserLookup(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	resp := &pb.GetUserResponse{}
+	resp.Profile = clib.Lookup() // want "potential nil field in gRPC response GetUserResponse.Profile"
+	return resp, nil
+}
@@ -0,0 +1,9 @@
+// Package zaplike is a minimal stand-in for a structured logging library
+// whose Fatal method exits the process.
+package zaplike
+
+// Logger writes log entries.
+type Logger struct{}
+
+// Fatal logs msg and exits the process.
+func (l *Logger) Fatal(msg string) {}
@@ -0,0 +1,52 @@
+package analyzer
+
+import "golang.org/x/tools/go/ssa"
+
+// maxFixpointIterations bounds the iteration of a value depending on
+// itself. Approximations are joined monotonically, so the bound is only a
+// safety net.
+const maxFixpointIterations = 16
+
+// valueCache memoizes value facts in the order they were computed, so that
+// the facts derived from a provisional approximation can be dropped.
+type valueCache struct {
+	facts map[ssa.Value]nilFact
+	order []ssa.Value
+}
+
+func newValueCache() *valueCache {
+	return &valueCache{facts: make(map[ssa.Value]nilFact)}
+}
+
+func (c *valueCache) get(v ssa.Value) (nilFact, bool) {
+	f, ok := c.facts[v]
+	return f, ok
+}
+
+func (c *valueCache) put(v ssa.Value, f nilFact) {
+	if _, ok := c.facts[v]; !ok {
+		c.order = append(c.order, v)
+	}
+	c.facts[v] = f
+}
+
+// len returns the number of cached facts.
+func (c *valueCache) len() int {
+	return len(c.order)
+}
+
+// truncate drops all but the first n cached facts.
+func (c *valueCache) truncate(n int) {
+	for _, v := range c.order[n:] {
+		delete(c.facts, v)
+	}
+	c.order = c.order[:n]
+}
+
+// pendingFact is the current approximation of a value whose fact is being
+// computed, and whether the computation depended on it.
+type pendingFact struct {
+	approx nilFact
+	cache  *valueCache
+	cyclic bool
+}